@@ -0,0 +1,100 @@
+package betwixt
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// The annotation headers are sent along with a request to describe its
+// endpoint. Every header starting with AnnotationPrefix is removed before the
+// request reaches the handler, so they're never documented.
+const (
+	AnnotationPrefix  = "X-Betwixt-"
+	SummaryHeader     = AnnotationPrefix + "Summary"
+	DescriptionHeader = AnnotationPrefix + "Description"
+	TagHeader         = AnnotationPrefix + "Tag"
+)
+
+// Annotation defines a way to describe the endpoint of a request
+type Annotation func(http.Header)
+
+// Summary sets a short summary of the endpoint
+func Summary(summary string) Annotation {
+	return func(h http.Header) {
+		h.Set(SummaryHeader, url.PathEscape(summary))
+	}
+}
+
+// Description sets a longer description of the endpoint
+func Description(description string) Annotation {
+	return func(h http.Header) {
+		h.Set(DescriptionHeader, url.PathEscape(description))
+	}
+}
+
+// Tag adds tags to the endpoint, which the outputs use to group endpoints
+// into sections.
+func Tag(tags ...string) Annotation {
+	return func(h http.Header) {
+		for _, v := range tags {
+			h.Add(TagHeader, url.PathEscape(v))
+		}
+	}
+}
+
+// Annotate adds the annotations to a request, before it's sent. The values
+// are escaped, so they can contain anything, including new lines.
+func Annotate(r *http.Request, annotations ...Annotation) {
+	if r.Header == nil {
+		r.Header = make(http.Header, 0)
+	}
+	for _, annotation := range annotations {
+		annotation(r.Header)
+	}
+}
+
+// hasAnnotations returns true if any of the headers are annotations
+func hasAnnotations(h http.Header) bool {
+	for k := range h {
+		if strings.HasPrefix(http.CanonicalHeaderKey(k), AnnotationPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// takeAnnotations returns the annotations from the headers, removing every
+// annotation header.
+func takeAnnotations(h http.Header) entry.Annotations {
+	res := entry.Annotations{
+		Summary:     unescape(h.Get(SummaryHeader)),
+		Description: unescape(h.Get(DescriptionHeader)),
+	}
+	// Tags can also be comma separated, so they're easy to set by hand.
+	for _, v := range h.Values(TagHeader) {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(unescape(tag)); len(tag) > 0 {
+				res.Tags = append(res.Tags, tag)
+			}
+		}
+	}
+
+	for k := range h {
+		if strings.HasPrefix(http.CanonicalHeaderKey(k), AnnotationPrefix) {
+			h.Del(k)
+		}
+	}
+	return res
+}
+
+// unescape unescapes a value set by an Annotation, values set by hand are
+// returned as they are.
+func unescape(value string) string {
+	if res, err := url.PathUnescape(value); err == nil {
+		return res
+	}
+	return value
+}
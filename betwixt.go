@@ -1,84 +1,183 @@
 package betwixt
 
 import (
-	"bytes"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"net/http/httptest"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/SimonRichardson/betwixt/pkg/entry"
+	"github.com/SimonRichardson/betwixt/pkg/session"
 )
 
 // Betwixt is a struct that holds all the entries and outputs to be processed
 type Betwixt struct {
-	mutex   sync.Mutex
-	entries []entry.Entry
-	outputs []Output
-	handler http.Handler
+	mutex      sync.Mutex
+	store      *store
+	outputs    []Output
+	handler    http.Handler
+	status     func(int) bool
+	limit      int64
+	redact     Redactor
+	routes     RouteResolver
+	session    *session.Writer
+	thresholds entry.Thresholds
+	merge      bool
+	err        error
 }
 
 // New creates a Betwixt for possible outputs
-func New(handler http.Handler, outputs []Output) *Betwixt {
-	return &Betwixt{
-		mutex:   sync.Mutex{},
-		outputs: outputs,
-		handler: handler,
+func New(handler http.Handler, outputs []Output, options ...Option) *Betwixt {
+	b := &Betwixt{
+		mutex:      sync.Mutex{},
+		store:      newStore(),
+		outputs:    outputs,
+		handler:    handler,
+		status:     AnyStatus,
+		limit:      DefaultCaptureLimit,
+		thresholds: entry.DefaultThresholds,
 	}
+	for _, option := range options {
+		option(b)
+	}
+	return b
 }
 
 // ServeHTTP handles all the middleware for creating the documents
 func (b *Betwixt) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	bodyBytes, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		panic(err)
+	started := time.Now()
+
+	// Annotations are removed, so they never reach the handler.
+	annotations := takeAnnotations(r.Header)
+
+	// The request body is copied as the handler reads it, only the start of
+	// it is held in memory.
+	body := newRequestBody(r.Body, b.limit)
+	r.Body = body
+
+	if b.routes != nil {
+		r = b.routes.Prepare(r)
 	}
 
-	r.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+	// Writes are passed straight through to the client, so streaming
+	// handlers still work, whilst a copy is kept for the documentation.
+	writer := newResponseWriter(w, b.limit)
+	b.handler.ServeHTTP(writer.wrap(), r)
+
+	duration := time.Since(started)
 
-	writer := httptest.NewRecorder()
-	b.handler.ServeHTTP(writer, r)
-	writer.Flush()
+	body.drain(r.ContentLength)
+	var (
+		reqBody  = body.Body()
+		respBody = writer.Body()
+	)
 
-	nativeHeaders := w.Header()
-	for k, v := range writer.Header() {
-		for _, v := range v {
-			nativeHeaders.Add(k, v)
+	var template string
+	if b.routes != nil {
+		if pattern, ok := b.routes.Resolve(r); ok {
+			template = entry.RouteTemplate(pattern)
 		}
 	}
-	w.WriteHeader(writer.Code)
-	w.Write(writer.Body.Bytes())
 
-	// Only handle successful status codes
-	if status := writer.Code; status >= 200 && status < 300 {
+	b.record(entry.Entry{
+		URL:        r.URL,
+		Origin:     origin(r),
+		Template:   template,
+		Started:    started,
+		Duration:   duration,
+		Method:     r.Method,
+		Status:     writer.Status(),
+		ReqHeaders: r.Header,
+		ReqBody: func() []byte {
+			return reqBody
+		},
+		RespHeaders: writer.Headers(),
+		RespBody: func() []byte {
+			return respBody
+		},
+		ReqSize:     requestSize(r.ContentLength, body.Size()),
+		RespSize:    writer.Size(),
+		Annotations: annotations,
+	})
+}
+
+// Add adds entries that have been captured elsewhere, for example from a
+// session, so they're documented along with any other entries.
+func (b *Betwixt) Add(entries ...entry.Entry) {
+	for _, v := range entries {
+		b.record(v)
+	}
+}
+
+// Merge adds all the entries captured by other instances, so they're
+// documented as a single set of documents. The entries have already been
+// filtered, redacted and persisted by the other instances, so they're stored
+// as they are.
+func (b *Betwixt) Merge(others ...*Betwixt) {
+	for _, v := range others {
+		if v == b {
+			continue
+		}
+		entries := v.Entries()
+
 		b.mutex.Lock()
-		defer b.mutex.Unlock()
-
-		b.entries = append(b.entries, entry.Entry{
-			URL:        r.URL,
-			Method:     r.Method,
-			Status:     writer.Code,
-			ReqHeaders: r.Header,
-			ReqBody: func() []byte {
-				return bodyBytes
-			},
-			RespHeaders: writer.Header(),
-			RespBody: func() []byte {
-				return writer.Body.Bytes()
-			},
-		})
+		for _, e := range entries {
+			b.store.add(e)
+		}
+		b.mutex.Unlock()
+	}
+}
+
+// Entries returns a copy of all the captured entries, that have been kept
+// within the sampling and the memory budget.
+func (b *Betwixt) Entries() []entry.Entry {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.store.entries()
+}
+
+// Documents returns all the captured entries grouped into documents
+func (b *Betwixt) Documents() ([]entry.Document, error) {
+	return b.group(b.Entries())
+}
+
+// record stores the entry for documenting, if it passes the status filter
+func (b *Betwixt) record(e entry.Entry) {
+	// Only handle the status codes that pass the filter
+	if !b.status(e.Status) {
+		return
+	}
+
+	if b.redact != nil {
+		e = b.redact.Redact(e)
 	}
+
+	// Persist the entry as soon as it arrives, so it isn't lost if the
+	// process exits before Output is called.
+	var err error
+	if b.session != nil {
+		err = b.session.Write(e)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+	b.store.add(e)
 }
 
-// Output the results
+// Output the results, along with any error from writing the session
 func (b *Betwixt) Output() error {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
-	grouped, err := group(b.entries)
+	grouped, err := b.group(b.store.entries())
 	if err != nil {
 		return err
 	}
@@ -89,7 +188,15 @@ func (b *Betwixt) Output() error {
 		}
 	}
 
-	return nil
+	// Report any failure to persist the session, once the outputs have been
+	// written.
+	return b.err
+}
+
+// Redactor defines an interface for removing sensitive data from an entry
+// before it's persisted or documented.
+type Redactor interface {
+	Redact(entry.Entry) entry.Entry
 }
 
 // Output defines an interface for consuming a document.
@@ -97,25 +204,33 @@ type Output interface {
 	Output([]entry.Document) error
 }
 
-func group(entries []entry.Entry) ([]entry.Document, error) {
-	// Group according to the url and status code.
-	groups := entry.Entries(entries).GroupBy(func(entry entry.Entry) string {
+func (b *Betwixt) group(entries []entry.Entry) ([]entry.Document, error) {
+	// Group according to the url and status code, once the variable path
+	// segments have been inferred.
+	groups := entry.Entries(entries).InferTemplates().GroupBy(func(entry entry.Entry) string {
 		url := fmt.Sprintf("%s/%s", entry.URL.Host, entry.NormalisePath())
 		return fmt.Sprintf("%s-%s-%d", entry.Method, url, entry.Status)
 	})
 
 	// Loop through all the groups and find differences.
-	return groups.Walk(func(entries entry.Entries) (entry.Document, error) {
-		url := entries.URL()
-		return entry.Document{
-			URL:         url,
-			Method:      entries.Method(),
-			Status:      entries.Status(),
-			Params:      entries.Params(),
-			ReqHeaders:  entries.ReqHeaders(),
-			ReqBody:     entries.ReqBody(),
-			RespHeaders: entries.RespHeaders(),
-			RespBody:    entries.RespBody(),
-		}, nil
+	docs, err := groups.Walk(func(entries entry.Entries) (entry.Document, error) {
+		return entries.Document(b.thresholds, b.merge), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort by path, method and then status, so the output is the same every
+	// time.
+	sort.SliceStable(docs, func(i, j int) bool {
+		a, b := docs[i], docs[j]
+		if x, y := a.URL.String(), b.URL.String(); x != y {
+			return x < y
+		}
+		if x, y := a.Method.String(), b.Method.String(); x != y {
+			return x < y
+		}
+		return a.Status.Union().Status < b.Status.Union().Status
 	})
+	return docs, nil
 }
@@ -1,6 +1,7 @@
 package betwixt_test
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -9,13 +10,21 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"testing/quick"
 
 	"github.com/SimonRichardson/betwixt"
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+	"github.com/SimonRichardson/betwixt/pkg/har"
 	"github.com/SimonRichardson/betwixt/pkg/output"
+	"github.com/SimonRichardson/betwixt/pkg/output/snippet"
+	"github.com/SimonRichardson/betwixt/pkg/redact"
+	"github.com/SimonRichardson/betwixt/pkg/session"
 )
 
 func TestPlaintext(t *testing.T) {
@@ -192,12 +201,1671 @@ func TestMarkdown(t *testing.T) {
 	})
 }
 
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	t.Run("basic", func(t *testing.T) {
+		handler := http.NewServeMux()
+		handler.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "application/json")
+			bytes, _ := json.Marshal(map[string]string{
+				"hello": "world",
+			})
+			w.WriteHeader(http.StatusOK)
+			w.Write(bytes)
+		})
+
+		var (
+			buffer  = new(bytes.Buffer)
+			outputs = []betwixt.Output{
+				output.NewOpenAPI(output.MakeWriter(buffer), output.NewOpenAPIOptions("Hello")),
+			}
+			capture = betwixt.New(handler, outputs)
+			server  = httptest.NewServer(capture)
+		)
+
+		request("GET", fmt.Sprintf("%s/hello", server.URL), nil, empty)
+		request("GET", fmt.Sprintf("%s/hello?possible=1", server.URL), nil, empty)
+
+		if err := capture.Output(); err != nil {
+			t.Fatal(err)
+		}
+
+		var spec struct {
+			OpenAPI string `json:"openapi"`
+			Info    struct {
+				Title string `json:"title"`
+			} `json:"info"`
+			Paths map[string]map[string]struct {
+				Parameters []struct {
+					Name     string `json:"name"`
+					In       string `json:"in"`
+					Required bool   `json:"required"`
+				} `json:"parameters"`
+				Responses map[string]struct {
+					Content map[string]struct {
+						Example map[string]string `json:"example"`
+					} `json:"content"`
+				} `json:"responses"`
+			} `json:"paths"`
+		}
+		if err := json.Unmarshal(buffer.Bytes(), &spec); err != nil {
+			t.Fatal(err)
+		}
+
+		if expected, actual := output.OpenAPIVersion, spec.OpenAPI; expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+		if expected, actual := "Hello", spec.Info.Title; expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+
+		operation, ok := spec.Paths["/hello"]["get"]
+		if !ok {
+			t.Fatalf("expected get /hello operation, actual: %v", spec.Paths)
+		}
+
+		var possible bool
+		for _, v := range operation.Parameters {
+			if v.Name == "possible" {
+				possible = v.In == "query" && !v.Required
+			}
+		}
+		if !possible {
+			t.Errorf("expected optional query parameter, actual: %v", operation.Parameters)
+		}
+
+		example := operation.Responses["200"].Content["application/json"].Example
+		if expected, actual := "world", example["hello"]; expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+	})
+}
+
+func TestSwagger2(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":1,"name":null}`))
+	})
+
+	var (
+		buffer  = new(bytes.Buffer)
+		outputs = []betwixt.Output{
+			output.NewSwagger2(output.MakeWriter(buffer), output.NewOpenAPIOptions("Users")),
+		}
+		capture = betwixt.New(handler, outputs)
+		server  = httptest.NewServer(capture)
+	)
+
+	request("GET", fmt.Sprintf("%s/users/1", server.URL), nil, empty)
+	request("GET", fmt.Sprintf("%s/users/2", server.URL), nil, empty)
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	var spec struct {
+		Swagger string `json:"swagger"`
+		Paths   map[string]map[string]struct {
+			Responses map[string]struct {
+				Schema struct {
+					Ref string `json:"$ref"`
+				} `json:"schema"`
+			} `json:"responses"`
+		} `json:"paths"`
+		Definitions map[string]struct {
+			Properties map[string]struct {
+				Type     string `json:"type"`
+				Nullable bool   `json:"x-nullable"`
+			} `json:"properties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, actual := output.SwaggerVersion, spec.Swagger; expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+
+	ref := spec.Paths["/users/{id}"]["get"].Responses["200"].Schema.Ref
+	if expected, actual := "#/definitions/GetUsersByIdResponse200", ref; expected != actual {
+		t.Fatalf("expected: %q, actual: %q\n%s", expected, actual, buffer.String())
+	}
+
+	name := spec.Definitions["GetUsersByIdResponse200"].Properties["name"]
+	if !name.Nullable {
+		t.Errorf("expected nullable name, actual: %v", name)
+	}
+
+	// Swagger 2.0 can't describe methods such as TRACE.
+	request("TRACE", fmt.Sprintf("%s/users/1", server.URL), nil, empty)
+	if err := capture.Output(); err == nil {
+		t.Error("expected error for an unsupported method")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var (
+		buffer  = new(bytes.Buffer)
+		outputs = []betwixt.Output{
+			output.NewJSON(output.MakeWriter(buffer)),
+		}
+		capture = betwixt.New(handler, outputs)
+		server  = httptest.NewServer(capture)
+	)
+
+	request("GET", fmt.Sprintf("%s/hello?a=1", server.URL), nil, empty)
+	request("GET", fmt.Sprintf("%s/hello?a=1&b=2", server.URL), nil, empty)
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	type valuesScore struct {
+		Values map[string][]string `json:"values"`
+		Score  float64             `json:"score"`
+	}
+	var docs []struct {
+		Method struct {
+			Union struct {
+				String string  `json:"string"`
+				Score  float64 `json:"score"`
+			} `json:"union"`
+		} `json:"method"`
+		Params struct {
+			Len        int           `json:"len"`
+			Union      valuesScore   `json:"union"`
+			Difference []valuesScore `json:"difference"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &docs); err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := 1, len(docs); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+
+	doc := docs[0]
+	if expected, actual := "GET", doc.Method.Union.String; expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := 1.0, doc.Method.Union.Score; expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if expected, actual := 2, doc.Params.Len; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := "1", strings.Join(doc.Params.Union.Values["a"], ""); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := 1, len(doc.Params.Difference); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := "2", strings.Join(doc.Params.Difference[0].Values["b"], ""); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestBlueprint(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":1,"name":"bob"}`))
+	})
+
+	var (
+		buffer  = new(bytes.Buffer)
+		outputs = []betwixt.Output{
+			output.NewBlueprint(output.MakeWriter(buffer), output.BlueprintOptions{Name: "Users"}),
+		}
+		capture = betwixt.New(handler, outputs)
+		server  = httptest.NewServer(capture)
+	)
+
+	request("GET", fmt.Sprintf("%s/users/1?expand=true", server.URL), nil, empty)
+	request("GET", fmt.Sprintf("%s/users/2", server.URL), nil, empty)
+	request("DELETE", fmt.Sprintf("%s/users/3", server.URL), nil, empty)
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	for v, expected := range map[string]int{
+		"FORMAT: 1A\n\n# Users\n":                                      1,
+		"\n# Group Users\n":                                            1,
+		"\n## Users By Id [/users/{id}{?expand}]\n":                    1,
+		"    + expand: `true` (optional, string)\n":                    1,
+		"\n### Retrieve Users By Id [GET]\n":                           1,
+		"\n### Delete Users By Id [DELETE]\n":                          1,
+		"\n+ Response 200 (application/json)\n":                        2,
+		"    + Attributes (object)\n        + id (number, required)\n": 2,
+	} {
+		if actual := strings.Count(buffer.String(), v); expected != actual {
+			t.Errorf("expected: %d of %q, actual: %d\n%s", expected, v, actual, buffer.String())
+		}
+	}
+}
+
+func TestPostman(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusCreated)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		w.Write([]byte(`{"id":1}`))
+	})
+
+	var (
+		buffer  = new(bytes.Buffer)
+		outputs = []betwixt.Output{
+			output.NewPostman(output.MakeWriter(buffer), "Users"),
+		}
+		capture = betwixt.New(handler, outputs)
+		server  = httptest.NewServer(capture)
+	)
+
+	request("GET", fmt.Sprintf("%s/users/1", server.URL), nil, empty)
+	request("GET", fmt.Sprintf("%s/users/2", server.URL), nil, empty)
+	request("POST", fmt.Sprintf("%s/users/3", server.URL), []byte(`{"name":"bob"}`), empty)
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	var collection struct {
+		Info struct {
+			Name   string `json:"name"`
+			Schema string `json:"schema"`
+		} `json:"info"`
+		Item []struct {
+			Request struct {
+				Method string `json:"method"`
+				Header []struct {
+					Key string `json:"key"`
+				} `json:"header"`
+				Body *struct {
+					Raw string `json:"raw"`
+				} `json:"body"`
+				URL struct {
+					Raw      string `json:"raw"`
+					Variable []struct {
+						Key string `json:"key"`
+					} `json:"variable"`
+				} `json:"url"`
+			} `json:"request"`
+			Response []struct {
+				Code int    `json:"code"`
+				Body string `json:"body"`
+			} `json:"response"`
+		} `json:"item"`
+		Variable []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"variable"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, actual := output.PostmanSchema, collection.Info.Schema; expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := 1, len(collection.Variable); expected != actual || collection.Variable[0].Key != "baseUrl" {
+		t.Fatalf("expected: %d baseUrl variable, actual: %d\n%s", expected, actual, buffer.String())
+	}
+	// The middleware receives relative URLs, so the origin is used instead.
+	if expected, actual := server.URL, collection.Variable[0].Value; expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := 2, len(collection.Item); expected != actual {
+		t.Fatalf("expected: %d, actual: %d\n%s", expected, actual, buffer.String())
+	}
+
+	for _, item := range collection.Item {
+		if expected, actual := "{{baseUrl}}/users/:id", item.Request.URL.Raw; expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+		if expected, actual := 1, len(item.Request.URL.Variable); expected != actual || item.Request.URL.Variable[0].Key != "id" {
+			t.Errorf("expected: %d id variable, actual: %d", expected, actual)
+		}
+		if expected, actual := 1, len(item.Response); expected != actual {
+			t.Fatalf("expected: %d, actual: %d", expected, actual)
+		}
+		for _, v := range item.Request.Header {
+			if entry.IsClientHeader(v.Key) {
+				t.Errorf("unexpected client header: %q", v.Key)
+			}
+		}
+
+		switch item.Request.Method {
+		case "GET":
+			if item.Request.Body != nil {
+				t.Errorf("expected no body, actual: %q", item.Request.Body.Raw)
+			}
+			if expected, actual := http.StatusOK, item.Response[0].Code; expected != actual {
+				t.Errorf("expected: %d, actual: %d", expected, actual)
+			}
+		case "POST":
+			if item.Request.Body == nil || item.Request.Body.Raw != `{"name":"bob"}` {
+				t.Errorf("expected body, actual: %v", item.Request.Body)
+			}
+			if expected, actual := http.StatusCreated, item.Response[0].Code; expected != actual {
+				t.Errorf("expected: %d, actual: %d", expected, actual)
+			}
+		default:
+			t.Errorf("unexpected method: %q", item.Request.Method)
+		}
+	}
+}
+
+func TestPostmanHosts(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var (
+		users  = httptest.NewTLSServer(handler)
+		orders = httptest.NewServer(handler)
+		buffer = new(bytes.Buffer)
+		// The client of the TLS server trusts its certificate.
+		transport = betwixt.NewTransport(users.Client().Transport, []betwixt.Output{
+			output.NewPostman(output.MakeWriter(buffer), "Services"),
+		})
+		client = &http.Client{Transport: transport}
+	)
+	defer users.Close()
+	defer orders.Close()
+
+	for _, v := range []string{users.URL + "/users", orders.URL + "/orders"} {
+		resp, err := client.Get(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if err := transport.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	var collection struct {
+		Item []struct {
+			Request struct {
+				URL struct {
+					Raw string `json:"raw"`
+				} `json:"url"`
+			} `json:"request"`
+		} `json:"item"`
+		Variable []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"variable"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every request keeps the scheme and host it was sent to.
+	variables := make(map[string]string, 0)
+	for _, v := range collection.Variable {
+		variables[v.Key] = v.Value
+	}
+	expected := map[string]string{
+		"/users":  users.URL,
+		"/orders": orders.URL,
+	}
+	if len(collection.Item) != len(expected) {
+		t.Fatalf("expected: %d, actual: %d\n%s", len(expected), len(collection.Item), buffer.String())
+	}
+	for _, item := range collection.Item {
+		raw := item.Request.URL.Raw
+		index := strings.Index(raw, "}}")
+		if !strings.HasPrefix(raw, "{{") || index < 0 {
+			t.Fatalf("expected a host variable, actual: %q", raw)
+		}
+		var (
+			path = raw[index+2:]
+			host = variables[raw[2:index]]
+		)
+		if expected, actual := expected[path], host; expected != actual {
+			t.Errorf("%s expected: %q, actual: %q", path, expected, actual)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"name":"<bob>","age":42}`))
+	})
+
+	var (
+		dir     = t.TempDir()
+		outputs = []betwixt.Output{
+			output.NewHTML(dir, output.HTMLOptions{Title: "Users"}),
+		}
+		capture = betwixt.New(handler, outputs)
+		server  = httptest.NewServer(capture)
+	)
+
+	request("GET", fmt.Sprintf("%s/users/1", server.URL), nil, empty)
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<a href="get-users-id.html">`; !strings.Contains(string(index), expected) {
+		t.Errorf("expected: %q, actual: \n%s", expected, index)
+	}
+
+	page, err := ioutil.ReadFile(filepath.Join(dir, "get-users-id.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{
+		`<span class="path">/users/{id}</span>`,
+		`<span class="key">&#34;name&#34;</span>: <span class="string">&#34;&lt;bob&gt;&#34;</span>`,
+		`<span class="number">42</span>`,
+	} {
+		if !strings.Contains(string(page), v) {
+			t.Errorf("expected: %q, actual: \n%s", v, page)
+		}
+	}
+}
+
+func TestSnippets(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var (
+		buffer  = new(bytes.Buffer)
+		outputs = []betwixt.Output{
+			output.NewMarkdown(output.MakeWriter(buffer), output.Options{
+				Snippets: []snippet.Language{snippet.Curl, snippet.Python},
+			}),
+		}
+		capture = betwixt.New(handler, outputs)
+		server  = httptest.NewServer(capture)
+	)
+
+	request("PUT", fmt.Sprintf("%s/users/1", server.URL), []byte(`{"name":"bob"}`), func(h http.Header) {
+		h.Set("Content-Type", "application/json")
+	})
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []string{
+		"    + Snippet (cURL)\n\n            curl -X PUT '" + server.URL + "/users/1' \\\n",
+		"              -H 'Content-Type: application/json' \\\n",
+		"              --data-raw '{\"name\":\"bob\"}'\n",
+		"    + Snippet (Python)\n\n            import requests\n",
+	} {
+		if !strings.Contains(buffer.String(), v) {
+			t.Errorf("expected: %q, actual: \n%s", v, buffer.String())
+		}
+	}
+
+	// Headers set by the client aren't copied into the snippets.
+	for _, v := range []string{"-H 'Accept-Encoding", "-H 'User-Agent"} {
+		if strings.Contains(buffer.String(), v) {
+			t.Errorf("unexpected: %q, actual: \n%s", v, buffer.String())
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":1}`))
+	})
+
+	var (
+		dir  = t.TempDir()
+		tmpl = filepath.Join(dir, "API.tmpl")
+		out  = filepath.Join(dir, "API.md")
+	)
+	if err := ioutil.WriteFile(tmpl, []byte(`{{range .Documents}}
+## {{(union .Method).String}} {{path (union .URL).HostPath.Path}} {{(union .Status).Status}} {{statusText (union .Status).Status}}
+{{range values (union .RespHeaders).Values}}{{.Key}}={{.Value}}
+{{end}}{{json .RespBody.String}}
+{{score (union .Params).Score}}
+{{end}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputs, err := betwixt.Parse(fmt.Sprintf("Template:%s,file:%s", tmpl, out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		capture = betwixt.New(handler, outputs)
+		server  = httptest.NewServer(capture)
+	)
+
+	request("GET", fmt.Sprintf("%s/users/1", server.URL), nil, empty)
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	bytes, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{
+		"## GET /users/{id} 200 OK\n",
+		"Content-Type=application/json\n",
+		"{\n  \"id\": 1\n}\n",
+		"1.00\n",
+	} {
+		if !strings.Contains(string(bytes), v) {
+			t.Errorf("expected: %q, actual: \n%s", v, bytes)
+		}
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	t.Parallel()
+
+	render := func() string {
+		handler := http.NewServeMux()
+		handler.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("fail") != "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+
+		var (
+			buffer  = new(bytes.Buffer)
+			outputs = []betwixt.Output{
+				output.NewMarkdown(output.MakeWriter(buffer), output.Options{
+					Optionals:     true,
+					OmitTimestamp: true,
+				}),
+			}
+			capture = betwixt.New(handler, outputs)
+			server  = httptest.NewServer(capture)
+		)
+		defer server.Close()
+
+		for _, v := range []string{"/b", "/a?x=1", "/a?x=2", "/a?y=1", "/b?fail=1", "/c"} {
+			get(t, server.URL+v)
+		}
+		request("POST", server.URL+"/a", nil, empty)
+
+		if err := capture.Output(); err != nil {
+			t.Fatal(err)
+		}
+		return buffer.String()
+	}
+
+	expected := render()
+	if strings.Contains(expected, "Date generated on") {
+		t.Errorf("expected no timestamp, actual: \n%s", expected)
+	}
+
+	var (
+		a = strings.Index(expected, "# GET /a")
+		b = strings.Index(expected, "# POST /a")
+		c = strings.Index(expected, "# GET /b")
+	)
+	if !(a >= 0 && a < b && b < c) {
+		t.Errorf("expected sorted documents, actual: \n%s", expected)
+	}
+
+	for i := 0; i < 10; i++ {
+		if actual := render(); expected != actual {
+			t.Fatalf("expected: \n%s\n, actual: \n%s", expected, actual)
+		}
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	t.Parallel()
+
+	render := func(options ...betwixt.Option) string {
+		handler := http.NewServeMux()
+		handler.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		var (
+			buffer  = new(bytes.Buffer)
+			outputs = []betwixt.Output{
+				output.NewMarkdown(output.MakeWriter(buffer), output.Options{
+					Optionals: true,
+					Presence:  true,
+				}),
+			}
+			capture = betwixt.New(handler, outputs, options...)
+			server  = httptest.NewServer(capture)
+		)
+		defer server.Close()
+
+		for i := 0; i < 10; i++ {
+			request("GET", fmt.Sprintf("%s/hello", server.URL), nil, func(h http.Header) {
+				if i > 0 {
+					h.Set("X-Trace", "on")
+				}
+				if i < 6 {
+					h.Set("X-Version", "a")
+				} else {
+					h.Set("X-Version", "b")
+				}
+			})
+		}
+
+		if err := capture.Output(); err != nil {
+			t.Fatal(err)
+		}
+		return buffer.String()
+	}
+
+	if expected, actual := "            X-Trace: on (optional, 90%)\n", render(); !strings.Contains(actual, expected) {
+		t.Errorf("expected: %q, actual: \n%s", expected, actual)
+	}
+
+	actual := render(betwixt.WithThresholds(entry.Thresholds{
+		Params:  1.0,
+		Headers: 0.9,
+		Body:    1.0,
+	}))
+	if expected := "            X-Trace: on (90%)\n"; !strings.Contains(actual, expected) {
+		t.Errorf("expected: %q, actual: \n%s", expected, actual)
+	}
+	if expected := "            User-Agent: Go-http-client/1.1 (100%)\n"; !strings.Contains(actual, expected) {
+		t.Errorf("expected: %q, actual: \n%s", expected, actual)
+	}
+
+	// Both values pass the threshold, so the most common one is chosen.
+	actual = render(betwixt.WithThresholds(entry.Thresholds{
+		Params:  1.0,
+		Headers: 0.4,
+		Body:    1.0,
+	}))
+	if expected := "            X-Version: a (100%)\n"; !strings.Contains(actual, expected) {
+		t.Errorf("expected: %q, actual: \n%s", expected, actual)
+	}
+}
+
+func TestParsePresence(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var (
+		dir       = t.TempDir()
+		markdown  = filepath.Join(dir, "API.md")
+		plaintext = filepath.Join(dir, "API.txt")
+	)
+	outputs, err := betwixt.Parse(fmt.Sprintf("markdown,file:%s,presence;plaintext,file:%s,presence", markdown, plaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		capture = betwixt.New(handler, outputs)
+		server  = httptest.NewServer(capture)
+	)
+	defer server.Close()
+
+	request("GET", fmt.Sprintf("%s/hello", server.URL), nil, empty)
+	request("GET", fmt.Sprintf("%s/hello", server.URL), nil, func(h http.Header) {
+		h.Set("X-Trace", "on")
+	})
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expected := range map[string]string{
+		markdown:  "User-Agent: Go-http-client/1.1 (100%)",
+		plaintext: "on (optional, 50%)",
+	} {
+		bytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := string(bytes); !strings.Contains(actual, expected) {
+			t.Errorf("expected: %q, actual: \n%s", expected, actual)
+		}
+	}
+}
+
+func TestPathTemplates(t *testing.T) {
+	t.Parallel()
+
+	t.Run("inferred", func(t *testing.T) {
+		handler := http.NewServeMux()
+		handler.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		var (
+			buffer  = new(bytes.Buffer)
+			outputs = []betwixt.Output{
+				output.NewPlaintext(output.MakeWriter(buffer)),
+			}
+			capture = betwixt.New(handler, outputs)
+			server  = httptest.NewServer(capture)
+		)
+
+		request("GET", fmt.Sprintf("%s/users/1", server.URL), nil, empty)
+		request("GET", fmt.Sprintf("%s/users/2", server.URL), nil, empty)
+		request("GET", fmt.Sprintf("%s/users/3f2504e0-4f89-11d3-9a0c-0305e82c3301", server.URL), nil, empty)
+		request("GET", fmt.Sprintf("%s/users/1/posts/2024-01-02", server.URL), nil, empty)
+
+		if err := capture.Output(); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, v := range []string{
+			"GET 204 - /users/:id\n",
+			"GET 204 - /users/:id/posts/:date\n",
+		} {
+			if expected, actual := 1, strings.Count(buffer.String(), v); expected != actual {
+				t.Errorf("expected: %d of %q, actual: %d\n%s", expected, v, actual, buffer.String())
+			}
+		}
+		if expected, actual := 3, strings.Count(buffer.String(), " ・ :"); expected != actual {
+			t.Errorf("expected: %d promoted parameters, actual: %d\n%s", expected, actual, buffer.String())
+		}
+	})
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		if r.URL.Query().Get("name") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"missing name"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"hello":"world"}`))
+	})
+
+	t.Run("responses", func(t *testing.T) {
+		var (
+			buffer  = new(bytes.Buffer)
+			outputs = []betwixt.Output{
+				output.NewMarkdown(output.MakeWriter(buffer), output.Options{}),
+			}
+			capture = betwixt.New(handler, outputs)
+			server  = httptest.NewServer(capture)
+		)
+
+		get(t, fmt.Sprintf("%s/hello?name=a", server.URL))
+		get(t, fmt.Sprintf("%s/hello", server.URL))
+
+		if err := capture.Output(); err != nil {
+			t.Fatal(err)
+		}
+
+		for v, expected := range map[string]int{
+			"# GET /hello\n":   1,
+			"+ Response 200\n": 1,
+			"+ Response 400\n": 1,
+		} {
+			if actual := strings.Count(buffer.String(), v); expected != actual {
+				t.Errorf("expected: %d of %q, actual: %d\n%s", expected, v, actual, buffer.String())
+			}
+		}
+		if index := strings.Index(buffer.String(), "+ Response 200"); index > strings.Index(buffer.String(), "+ Response 400") {
+			t.Errorf("expected responses ordered by status\n%s", buffer.String())
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		var (
+			buffer  = new(bytes.Buffer)
+			outputs = []betwixt.Output{
+				output.NewMarkdown(output.MakeWriter(buffer), output.Options{}),
+			}
+			capture = betwixt.New(handler, outputs, betwixt.WithStatusFilter(betwixt.SuccessfulStatus))
+			server  = httptest.NewServer(capture)
+		)
+
+		get(t, fmt.Sprintf("%s/hello?name=a", server.URL))
+		get(t, fmt.Sprintf("%s/hello", server.URL))
+
+		if err := capture.Output(); err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(buffer.String(), "+ Response 400") {
+			t.Errorf("expected filtered response, actual: \n%s", buffer.String())
+		}
+	})
+}
+
+func TestStreaming(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Error("expected http.Flusher")
+			return
+		}
+		w.Header().Set("content-type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "data: 1\n\n")
+		flusher.Flush()
+
+		<-release
+		fmt.Fprint(w, "data: 2\n\n")
+	})
+
+	var (
+		buffer  = new(bytes.Buffer)
+		outputs = []betwixt.Output{
+			output.NewPlaintext(output.MakeWriter(buffer)),
+		}
+		capture = betwixt.New(handler, outputs, betwixt.WithCaptureLimit(12))
+		server  = httptest.NewServer(capture)
+	)
+
+	resp, err := http.Get(fmt.Sprintf("%s/events", server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// The first event must arrive before the handler has finished.
+	first := make([]byte, len("data: 1\n\n"))
+	if _, err := io.ReadFull(resp.Body, first); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+
+	rest, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := "data: 1\n\ndata: 2\n\n", string(first)+string(rest); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+
+	// Wait for the handler to complete before rendering.
+	server.Close()
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := "\n  data: 1\n\ndat"+betwixt.TruncationMarker+"\n", buffer.String()[strings.Index(buffer.String(), "- Response Body:")+len("- Response Body:\n"):]; expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestFullDuplex(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		controller := http.NewResponseController(w)
+		if err := controller.EnableFullDuplex(); err != nil {
+			t.Error(err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		controller.Flush()
+
+		// Each line is echoed as soon as it arrives, before the rest of the
+		// request body has been sent.
+		reader := bufio.NewReader(r.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fmt.Fprint(w, line)
+			controller.Flush()
+		}
+	})
+
+	var (
+		capture = betwixt.New(handler, nil)
+		server  = httptest.NewServer(capture)
+	)
+	defer server.Close()
+
+	reader, writer := io.Pipe()
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/echo", server.URL), reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+
+		body := bufio.NewReader(resp.Body)
+		for _, v := range []string{"hello\n", "world\n"} {
+			fmt.Fprint(writer, v)
+			line, err := body.ReadString('\n')
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if expected, actual := v, line; expected != actual {
+				t.Errorf("expected: %q, actual: %q", expected, actual)
+			}
+		}
+		writer.Close()
+		ioutil.ReadAll(resp.Body)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		writer.CloseWithError(fmt.Errorf("timed out"))
+		t.Fatal("expected the request to be streamed to the handler")
+	}
+
+	// Wait for the handler to complete before reading the entries.
+	server.Close()
+
+	entries := capture.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected: 1, actual: %d", len(entries))
+	}
+	if expected, actual := "hello\nworld\n", string(entries[0].ReqBody()); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+
+	var (
+		buffer    = new(bytes.Buffer)
+		server    = httptest.NewServer(handler)
+		transport = betwixt.NewTransport(nil, []betwixt.Output{
+			output.NewPlaintext(output.MakeWriter(buffer)),
+		})
+		client = &http.Client{Transport: transport}
+	)
+	defer server.Close()
+
+	resp, err := client.Post(fmt.Sprintf("%s/hello", server.URL), "application/json", strings.NewReader(`{"hello":"world"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := `{"hello":"world"}`, string(body); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+
+	if err := transport.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	for _, v := range []string{
+		fmt.Sprintf("POST 201 - %s/hello\n", host),
+		"- Request Body:\n\n  {\"hello\":\"world\"}\n",
+		"- Response Body:\n\n  {\"hello\":\"world\"}\n",
+	} {
+		if !strings.Contains(buffer.String(), v) {
+			t.Errorf("expected: %q, actual: \n%s", v, buffer.String())
+		}
+	}
+}
+
+func TestHAR(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var (
+		capture = betwixt.New(handler, nil)
+		server  = httptest.NewServer(capture)
+	)
+	defer server.Close()
+
+	request("GET", fmt.Sprintf("%s/hello?a=1", server.URL), nil, empty)
+	request("GET", fmt.Sprintf("%s/hello?a=2", server.URL), nil, func(h http.Header) {
+		h.Set("X-Forwarded-Proto", "https")
+	})
+
+	var buffer bytes.Buffer
+	if err := har.Write(&buffer, capture.Entries()); err != nil {
+		t.Fatal(err)
+	}
+
+	// HAR requires absolute URLs, even though the server only sees the path.
+	host := strings.TrimPrefix(server.URL, "http://")
+	for _, v := range []string{
+		fmt.Sprintf(`"url": "http://%s/hello?a=1"`, host),
+		fmt.Sprintf(`"url": "https://%s/hello?a=2"`, host),
+	} {
+		if !strings.Contains(buffer.String(), v) {
+			t.Errorf("expected: %q, actual: \n%s", v, buffer.String())
+		}
+	}
+}
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	})
+
+	var (
+		buffer  = new(bytes.Buffer)
+		persist = new(bytes.Buffer)
+		outputs = []betwixt.Output{
+			output.NewPlaintext(output.MakeWriter(buffer)),
+		}
+		capture = betwixt.New(handler, outputs, betwixt.WithSession(session.NewWriter(persist)))
+		server  = httptest.NewServer(capture)
+	)
+
+	request("POST", fmt.Sprintf("%s/hello?name=a", server.URL), []byte(`{"hello":"world"}`), func(h http.Header) {
+		h.Set("Content-Type", "application/json")
+	})
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := session.Read(persist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := 1, len(entries); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+
+	var (
+		rendered = new(bytes.Buffer)
+		render   = betwixt.New(nil, []betwixt.Output{
+			output.NewPlaintext(output.MakeWriter(rendered)),
+		})
+	)
+	render.Add(entries...)
+
+	if err := render.Output(); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, actual := buffer.String(), rendered.String(); expected != actual {
+		t.Errorf("expected: \n%s\n, actual: \n%s\n", expected, actual)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// Both instances share a session and a redactor, as they would when
+	// capturing separate services in the same test run.
+	var (
+		buffer  = new(bytes.Buffer)
+		writer  = session.NewWriter(buffer)
+		options = []betwixt.Option{
+			betwixt.WithSession(writer),
+			betwixt.WithRedactor(redact.New(redact.Header("X-Token", redact.Hash))),
+		}
+		users  = betwixt.New(handler, nil, options...)
+		orders = betwixt.New(handler, nil, options...)
+	)
+	for path, capture := range map[string]*betwixt.Betwixt{
+		"users":  users,
+		"orders": orders,
+	} {
+		server := httptest.NewServer(capture)
+		request("GET", fmt.Sprintf("%s/%s", server.URL, path), nil, func(h http.Header) {
+			h.Set("X-Token", "secret")
+		})
+		server.Close()
+	}
+
+	users.Merge(orders, users)
+
+	// The merged entries aren't redacted or persisted again.
+	for _, v := range users.Entries() {
+		if expected, actual := redact.Hash("secret"), v.ReqHeaders.Get("X-Token"); expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+	}
+	if expected, actual := 2, strings.Count(buffer.String(), "\n"); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+
+	docs, err := users.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := 2, len(docs); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := 1, len(orders.Entries()); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var (
+		capture = betwixt.New(handler, nil, betwixt.WithSampling(5))
+		server  = httptest.NewServer(capture)
+	)
+	defer server.Close()
+
+	for i := 0; i < 50; i++ {
+		request("GET", fmt.Sprintf("%s/users/%d", server.URL, i), nil, empty)
+		request("GET", fmt.Sprintf("%s/orders", server.URL), nil, empty)
+	}
+
+	// Ids are treated as the same endpoint, so both are sampled.
+	counts := make(map[string]int, 0)
+	for _, v := range capture.Entries() {
+		counts[strings.Split(v.URL.Path, "/")[1]]++
+	}
+	for _, v := range []string{"users", "orders"} {
+		if expected, actual := 5, counts[v]; expected != actual {
+			t.Errorf("%s expected: %d, actual: %d", v, expected, actual)
+		}
+	}
+}
+
+func TestMemoryBudget(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write(bytes.Repeat([]byte("a"), 100))
+	})
+
+	var (
+		capture = betwixt.New(handler, nil, betwixt.WithMemoryBudget(2000))
+		server  = httptest.NewServer(capture)
+	)
+	defer server.Close()
+
+	for i := 0; i < 50; i++ {
+		request("GET", fmt.Sprintf("%s/users", server.URL), nil, empty)
+	}
+	request("GET", fmt.Sprintf("%s/orders", server.URL), nil, empty)
+
+	var (
+		entries = capture.Entries()
+		size    int
+		orders  int
+	)
+	for _, v := range entries {
+		size += len(v.RespBody())
+		if v.URL.Path == "/orders" {
+			orders++
+		}
+	}
+	if len(entries) < 1 || size > 2000 {
+		t.Errorf("expected entries within the budget, actual: %d entries, %d bytes", len(entries), size)
+	}
+	// The largest endpoint is discarded from first.
+	if expected, actual := 1, orders; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
+
+func TestRequestTruncation(t *testing.T) {
+	t.Parallel()
+
+	var (
+		payload  = bytes.Repeat([]byte("a"), 100)
+		received []byte
+	)
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		received, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var (
+		capture = betwixt.New(handler, nil, betwixt.WithCaptureLimit(10))
+		server  = httptest.NewServer(capture)
+	)
+	defer server.Close()
+
+	request("POST", fmt.Sprintf("%s/upload", server.URL), payload, empty)
+
+	// The handler still receives the whole body.
+	if expected, actual := string(payload), string(received); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+
+	entries := capture.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected: 1, actual: %d", len(entries))
+	}
+	if expected, actual := "aaaaaaaaaa"+betwixt.TruncationMarker, string(entries[0].ReqBody()); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestRedactTruncation(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	var (
+		buffer  = new(bytes.Buffer)
+		outputs = []betwixt.Output{
+			output.NewPlaintext(output.MakeWriter(buffer)),
+		}
+		capture = betwixt.New(handler, outputs,
+			betwixt.WithCaptureLimit(40),
+			betwixt.WithRedactor(redact.Defaults()),
+		)
+		server = httptest.NewServer(capture)
+	)
+	defer server.Close()
+
+	payload := `{"password":"hunter2","padding":"` + strings.Repeat("x", 100) + `"}`
+	request("POST", fmt.Sprintf("%s/login", server.URL), []byte(payload), empty)
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buffer.String(), "hunter2") {
+		t.Errorf("expected the truncated body to be redacted, actual: \n%s", buffer.String())
+	}
+}
+
+func TestRouteResolver(t *testing.T) {
+	t.Parallel()
+
+	paths := func(capture *betwixt.Betwixt) []string {
+		docs, err := capture.Documents()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for _, v := range docs {
+			res = append(res, v.URL.Union().HostPath.Path)
+		}
+		return res
+	}
+
+	t.Run("serve mux", func(t *testing.T) {
+		// GODEBUG=httpmuxgo121=1 (the default outside a go 1.22+ module)
+		// treats patterns as literal paths and never sets Request.Pattern.
+		var pattern string
+		probe := http.NewServeMux()
+		probe.HandleFunc("GET /{name}", func(w http.ResponseWriter, r *http.Request) {
+			pattern = r.Pattern
+		})
+		probe.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/alice", nil))
+		if pattern == "" {
+			t.Skip("ServeMux patterns are unavailable")
+		}
+
+		handler := http.NewServeMux()
+		handler.HandleFunc("GET /users/{name}/posts/{post}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		var (
+			capture = betwixt.New(handler, nil, betwixt.WithRouteResolver(betwixt.ServeMuxResolver{}))
+			server  = httptest.NewServer(capture)
+		)
+		defer server.Close()
+
+		// Neither segment would be inferred from so few requests.
+		request("GET", fmt.Sprintf("%s/users/alice/posts/hello", server.URL), nil, empty)
+		request("GET", fmt.Sprintf("%s/users/bob/posts/world", server.URL), nil, empty)
+
+		if expected, actual := []string{"/users/:name/posts/:post"}, paths(capture); strings.Join(expected, ",") != strings.Join(actual, ",") {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+
+		docs, err := capture.Documents()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := docs[0].Params.Union().Values[":name"]; !ok {
+			t.Errorf("expected :name param, actual: %v", docs[0].Params.Union())
+		}
+	})
+
+	t.Run("context", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			betwixt.SetRoute(r, "/teams/{team:[a-z]+}")
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		var (
+			capture = betwixt.New(handler, nil, betwixt.WithRouteResolver(betwixt.ContextResolver{}))
+			server  = httptest.NewServer(capture)
+		)
+		defer server.Close()
+
+		request("GET", fmt.Sprintf("%s/teams/red", server.URL), nil, empty)
+		request("GET", fmt.Sprintf("%s/teams/blue", server.URL), nil, empty)
+
+		if expected, actual := []string{"/teams/:team"}, paths(capture); strings.Join(expected, ",") != strings.Join(actual, ",") {
+			t.Errorf("expected: %v, actual: %v", expected, actual)
+		}
+	})
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		w.Write(bytes.Repeat(body, 3))
+	})
+
+	var (
+		buffer  = new(bytes.Buffer)
+		outputs = []betwixt.Output{
+			output.NewMarkdown(output.MakeWriter(buffer), output.Options{
+				Stats: true,
+			}),
+		}
+		capture = betwixt.New(handler, outputs, betwixt.WithCaptureLimit(4))
+		server  = httptest.NewServer(capture)
+	)
+	defer server.Close()
+
+	for i := 0; i < 3; i++ {
+		request("POST", fmt.Sprintf("%s/echo", server.URL), []byte("0123456789"), empty)
+	}
+
+	docs, err := capture.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := docs[0].Stats
+	if expected, actual := 3, stats.Len(); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	// Sizes include the bytes beyond the capture limit.
+	if expected, actual := 10.0, stats.AvgReqSize(); expected != actual {
+		t.Errorf("expected: %f, actual: %f", expected, actual)
+	}
+	if expected, actual := 30.0, stats.AvgRespSize(); expected != actual {
+		t.Errorf("expected: %f, actual: %f", expected, actual)
+	}
+	if stats.Min() <= 0 || stats.Min() > stats.P50() || stats.P50() > stats.P95() || stats.P95() > stats.Max() {
+		t.Errorf("expected ordered latencies, actual: %s %s %s %s", stats.Min(), stats.P50(), stats.P95(), stats.Max())
+	}
+
+	if err := capture.Output(); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"    + Stats\n\n            Requests: 3\n            Latency: min ",
+		"            Request size: 10 B (average)\n",
+		"            Response size: 30 B (average)\n",
+	} {
+		if actual := buffer.String(); !strings.Contains(actual, expected) {
+			t.Errorf("expected: %q, actual: \n%s", expected, actual)
+		}
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mutex  sync.Mutex
+		leaked []string
+	)
+	handler := http.NewServeMux()
+	handler.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		for k := range r.Header {
+			if strings.HasPrefix(k, betwixt.AnnotationPrefix) {
+				leaked = append(leaked, k)
+			}
+		}
+		mutex.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	send := func(client *http.Client, url string, annotations ...betwixt.Annotation) {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		betwixt.Annotate(req, annotations...)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	t.Run("middleware", func(t *testing.T) {
+		var (
+			markdown  = new(bytes.Buffer)
+			plaintext = new(bytes.Buffer)
+			openapi   = new(bytes.Buffer)
+			outputs   = []betwixt.Output{
+				output.NewMarkdown(output.MakeWriter(markdown), output.Options{}),
+				output.NewPlaintext(output.MakeWriter(plaintext)),
+				output.NewOpenAPI(output.MakeWriter(openapi), output.NewOpenAPIOptions("API")),
+			}
+			capture = betwixt.New(handler, outputs)
+			server  = httptest.NewServer(capture)
+		)
+		defer server.Close()
+
+		send(http.DefaultClient, fmt.Sprintf("%s/users", server.URL),
+			betwixt.Summary("List users"),
+			betwixt.Description("Returns every user.\nOne page at a time."),
+			betwixt.Tag("users", "admin"),
+		)
+		send(http.DefaultClient, fmt.Sprintf("%s/users", server.URL), betwixt.Tag("users"))
+		send(http.DefaultClient, fmt.Sprintf("%s/health", server.URL))
+
+		if err := capture.Output(); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "# Group admin\n\n# GET /users\n\nList users\n\nReturns every user.\nOne page at a time.\n\n"
+		if actual := markdown.String(); !strings.Contains(actual, expected) {
+			t.Errorf("expected: %q, actual: \n%s", expected, actual)
+		}
+		// Untagged endpoints come before any of the groups.
+		if actual := markdown.String(); strings.Index(actual, "/health") > strings.Index(actual, "# Group") {
+			t.Errorf("expected /health before the groups, actual: \n%s", actual)
+		}
+
+		expected = "== admin ==\n\nGET 200 - /users\n- Summary: List users\n"
+		if actual := plaintext.String(); !strings.Contains(actual, expected) {
+			t.Errorf("expected: %q, actual: \n%s", expected, actual)
+		}
+		if actual := plaintext.String(); strings.Index(actual, "/health") > strings.Index(actual, "== admin ==") {
+			t.Errorf("expected /health before the sections, actual: \n%s", actual)
+		}
+
+		var spec struct {
+			Tags []struct {
+				Name string `json:"name"`
+			} `json:"tags"`
+			Paths map[string]map[string]struct {
+				Tags        []string `json:"tags"`
+				Summary     string   `json:"summary"`
+				Description string   `json:"description"`
+			} `json:"paths"`
+		}
+		if err := json.Unmarshal(openapi.Bytes(), &spec); err != nil {
+			t.Fatal(err)
+		}
+		op := spec.Paths["/users"]["get"]
+		if expected, actual := "List users", op.Summary; expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+		if expected, actual := "admin,users", strings.Join(op.Tags, ","); expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+		if expected, actual := 2, len(spec.Tags); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+	})
+
+	t.Run("transport", func(t *testing.T) {
+		var (
+			buffer    = new(bytes.Buffer)
+			server    = httptest.NewServer(handler)
+			transport = betwixt.NewTransport(nil, []betwixt.Output{
+				output.NewPlaintext(output.MakeWriter(buffer)),
+			})
+			client = &http.Client{Transport: transport}
+		)
+		defer server.Close()
+
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/orders", server.URL), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		betwixt.Annotate(req, betwixt.Summary("List orders"))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		// The caller's request isn't modified.
+		if expected, actual := "List%20orders", req.Header.Get(betwixt.SummaryHeader); expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+
+		if err := transport.Output(); err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := "- Summary: List orders\n", buffer.String(); !strings.Contains(actual, expected) {
+			t.Errorf("expected: %q, actual: \n%s", expected, actual)
+		}
+	})
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(leaked) > 0 {
+		t.Errorf("expected no annotation headers, actual: %v", leaked)
+	}
+}
+
 func errored(s int) bool {
 	return !(s == http.StatusOK || s == http.StatusCreated || s == http.StatusNoContent)
 }
 
 func empty(http.Header) {}
 
+func get(t *testing.T, url string) {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
 func request(reqType string, url string, payload []byte, fn func(http.Header)) []byte {
 	client := &http.Client{}
 	req, err := http.NewRequest(reqType, url, bytes.NewBuffer(payload))
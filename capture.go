@@ -0,0 +1,305 @@
+package betwixt
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultCaptureLimit is the default amount of bytes of a request or response
+// body that are captured for documentation.
+const DefaultCaptureLimit = 1 << 20
+
+// TruncationMarker is appended to any captured body that was larger than the
+// capture limit.
+const TruncationMarker = "...[truncated]"
+
+// responseWriter passes everything straight through to the client, whilst
+// copying the status, headers and body (up to a limit) for documentation.
+type responseWriter struct {
+	http.ResponseWriter
+	status    int
+	header    http.Header
+	body      bytes.Buffer
+	limit     int64
+	truncated bool
+	size      int64
+}
+
+func newResponseWriter(w http.ResponseWriter, limit int64) *responseWriter {
+	return &responseWriter{
+		ResponseWriter: w,
+		limit:          limit,
+	}
+}
+
+// WriteHeader records the status and a snapshot of the headers, before
+// writing them to the client.
+func (w *responseWriter) WriteHeader(status int) {
+	// Informational headers can be written multiple times, so wait for the
+	// final status.
+	if w.status == 0 && (status >= 200 || status == http.StatusSwitchingProtocols) {
+		w.status = status
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write copies the bytes into the capture, up to the limit, before writing
+// them to the client.
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if remaining := w.limit - int64(w.body.Len()); int64(len(p)) > remaining {
+		w.truncated = true
+		if remaining > 0 {
+			w.body.Write(p[:remaining])
+		}
+	} else {
+		w.body.Write(p)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush sends any buffered data to the client.
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, from then on nothing more
+// is captured.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+	return hijacker.Hijack()
+}
+
+// Push initiates a HTTP/2 server push.
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use with
+// http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the status written to the client.
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Headers returns the headers as they were when the status was written.
+func (w *responseWriter) Headers() http.Header {
+	if w.header == nil {
+		return w.ResponseWriter.Header().Clone()
+	}
+	return w.header
+}
+
+// Size returns the amount of bytes of the body written to the client.
+func (w *responseWriter) Size() int64 {
+	return w.size
+}
+
+// Body returns the captured body.
+func (w *responseWriter) Body() []byte {
+	return markTruncated(w.body.Bytes(), w.truncated)
+}
+
+// requestBody copies the body (up to a limit) as it's read by the handler or
+// the transport, so nothing is read ahead of them and streaming requests still
+// work.
+type requestBody struct {
+	io.ReadCloser
+	mutex     sync.Mutex
+	body      bytes.Buffer
+	limit     int64
+	truncated bool
+	size      int64
+}
+
+func newRequestBody(body io.ReadCloser, limit int64) *requestBody {
+	return &requestBody{
+		ReadCloser: body,
+		limit:      limit,
+	}
+}
+
+func (r *requestBody) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+
+	// A transport may still be sending the body when the entry is recorded.
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.size += int64(n)
+	if remaining := r.limit - int64(r.body.Len()); int64(n) > remaining {
+		r.truncated = true
+		if remaining > 0 {
+			r.body.Write(p[:remaining])
+		}
+	} else {
+		r.body.Write(p[:n])
+	}
+	return n, err
+}
+
+// drain reads the rest of a body the handler didn't read, up to the limit, so
+// it's still documented. Only bodies of a known length are read, as the client
+// of a streaming body may be waiting on the response before it finishes.
+func (r *requestBody) drain(contentLength int64) {
+	r.mutex.Lock()
+	remaining := r.limit - int64(r.body.Len())
+	done := r.truncated || r.size >= contentLength
+	r.mutex.Unlock()
+
+	if !done {
+		// Read one more byte than the limit, to know if the body was
+		// truncated.
+		io.CopyN(ioutil.Discard, r, remaining+1)
+	}
+}
+
+// Body returns the captured body.
+func (r *requestBody) Body() []byte {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	body := append([]byte(nil), r.body.Bytes()...)
+	return markTruncated(body, r.truncated)
+}
+
+// Size returns the amount of bytes of the body that have been read.
+func (r *requestBody) Size() int64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.size
+}
+
+// requestSize returns the size of the request body, from the content length
+// if the body wasn't read in full.
+func requestSize(contentLength, read int64) int64 {
+	if contentLength > read {
+		return contentLength
+	}
+	return read
+}
+
+// origin returns the scheme and host the request was received on, trusting the
+// X-Forwarded-Proto header of any proxy in front of the server.
+func origin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); len(proto) > 0 {
+		scheme = strings.ToLower(strings.TrimSpace(strings.Split(proto, ",")[0]))
+	}
+	return scheme + "://" + r.Host
+}
+
+// markTruncated appends the TruncationMarker to the body, if it was truncated.
+func markTruncated(body []byte, truncated bool) []byte {
+	if !truncated {
+		return body
+	}
+	res := make([]byte, 0, len(body)+len(TruncationMarker))
+	return append(append(res, body...), TruncationMarker...)
+}
+
+type unwrapper interface {
+	Unwrap() http.ResponseWriter
+}
+
+// wrap returns a http.ResponseWriter that only implements the optional
+// interfaces that the underlying http.ResponseWriter implements, so handlers
+// that check for them still behave correctly.
+func (w *responseWriter) wrap() http.ResponseWriter {
+	var (
+		_, flusher  = w.ResponseWriter.(http.Flusher)
+		_, hijacker = w.ResponseWriter.(http.Hijacker)
+		_, pusher   = w.ResponseWriter.(http.Pusher)
+	)
+
+	switch {
+	case flusher && hijacker && pusher:
+		return struct {
+			http.ResponseWriter
+			unwrapper
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{w, w, w, w, w}
+	case flusher && hijacker:
+		return struct {
+			http.ResponseWriter
+			unwrapper
+			http.Flusher
+			http.Hijacker
+		}{w, w, w, w}
+	case flusher && pusher:
+		return struct {
+			http.ResponseWriter
+			unwrapper
+			http.Flusher
+			http.Pusher
+		}{w, w, w, w}
+	case hijacker && pusher:
+		return struct {
+			http.ResponseWriter
+			unwrapper
+			http.Hijacker
+			http.Pusher
+		}{w, w, w, w}
+	case flusher:
+		return struct {
+			http.ResponseWriter
+			unwrapper
+			http.Flusher
+		}{w, w, w}
+	case hijacker:
+		return struct {
+			http.ResponseWriter
+			unwrapper
+			http.Hijacker
+		}{w, w, w}
+	case pusher:
+		return struct {
+			http.ResponseWriter
+			unwrapper
+			http.Pusher
+		}{w, w, w}
+	}
+	return struct {
+		http.ResponseWriter
+		unwrapper
+	}{w, w}
+}
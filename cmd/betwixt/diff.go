@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/SimonRichardson/betwixt"
+	"github.com/SimonRichardson/betwixt/pkg/diff"
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+func runDiff(args []string) error {
+	var (
+		flags    = flag.NewFlagSet("diff", flag.ExitOnError)
+		breaking = flags.Bool("breaking", false, "only report the breaking changes")
+	)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: betwixt diff [flags] <old> <new>\n\n")
+		fmt.Fprintf(flags.Output(), "Compares the documents of two session or HAR files (or glob patterns of\n")
+		fmt.Fprintf(flags.Output(), "them) and reports the changes. Exits with a non-zero status if any of the\n")
+		fmt.Fprintf(flags.Output(), "changes are breaking.\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected an old and a new session file")
+	}
+
+	before, err := readDocuments(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	after, err := readDocuments(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	changes := diff.Compare(before, after)
+	if *breaking {
+		changes = diff.Breaking(changes)
+	}
+	for _, v := range changes {
+		kind := "non-breaking"
+		if v.Breaking {
+			kind = "breaking"
+		}
+		fmt.Fprintf(os.Stdout, "%-12s  %s\n", kind, v)
+	}
+
+	if n := len(diff.Breaking(changes)); n > 0 {
+		return fmt.Errorf("breaking changes found: %d", n)
+	}
+	return nil
+}
+
+// readDocuments reads the entries of all the files matching the pattern, and
+// groups them into documents.
+func readDocuments(pattern string) ([]entry.Document, error) {
+	paths, err := expandPaths([]string{pattern})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readEntries(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	capture := betwixt.New(nil, nil)
+	capture.Add(entries...)
+	return capture.Documents()
+}
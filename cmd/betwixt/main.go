@@ -0,0 +1,45 @@
+// Command betwixt generates documentation from http traffic, without needing
+// the middleware to be constructed within a Go test suite.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: betwixt <command> [flags]
+
+Commands:
+  proxy   Start a recording reverse proxy in front of an upstream url
+  render  Render documentation from one or more session files
+  diff    Report the changes between two session files, failing on breaking changes
+
+Run "betwixt <command> -h" for more information about a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "proxy":
+		err = runProxy(args)
+	case "render":
+		err = runRender(args)
+	case "diff":
+		err = runDiff(args)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "betwixt: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/SimonRichardson/betwixt"
+	"github.com/SimonRichardson/betwixt/pkg/har"
+	"github.com/SimonRichardson/betwixt/pkg/session"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+// proxyConfig is the configuration of the recording proxy
+type proxyConfig struct {
+	Upstream string
+	Outputs  string
+	Session  string
+	Archive  string
+	Limit    int64
+	Sample   int
+	Budget   int64
+}
+
+// proxy is a reverse proxy to the upstream service that captures every
+// request, writing the documentation when it's closed.
+type proxy struct {
+	capture *betwixt.Betwixt
+	session *session.Writer
+	archive string
+}
+
+// newProxy validates the config and creates a proxy to the upstream service.
+func newProxy(config proxyConfig) (*proxy, error) {
+	if len(config.Upstream) < 1 {
+		return nil, fmt.Errorf("no upstream url")
+	}
+	target, err := url.Parse(config.Upstream)
+	if err != nil {
+		return nil, err
+	}
+	if len(target.Scheme) < 1 || len(target.Host) < 1 {
+		return nil, fmt.Errorf("invalid upstream url %q", config.Upstream)
+	}
+
+	out, err := betwixt.Parse(config.Outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &proxy{
+		archive: config.Archive,
+	}
+	options := []betwixt.Option{
+		betwixt.WithCaptureLimit(config.Limit),
+		betwixt.WithSampling(config.Sample),
+		betwixt.WithMemoryBudget(config.Budget),
+	}
+	if len(config.Session) > 0 {
+		if p.session, err = session.Create(config.Session); err != nil {
+			return nil, err
+		}
+		options = append(options, betwixt.WithSession(p.session))
+	}
+
+	handler := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.SetXForwarded()
+		},
+	}
+	p.capture = betwixt.New(handler, out, options...)
+	return p, nil
+}
+
+// ServeHTTP proxies and captures the request
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.capture.ServeHTTP(w, r)
+}
+
+// Close writes the HAR archive and the documentation of every captured
+// request, then closes the session.
+func (p *proxy) Close() error {
+	if p.session != nil {
+		defer p.session.Close()
+	}
+
+	if len(p.archive) > 0 {
+		log.Printf("writing %s", p.archive)
+		if err := har.WriteFile(p.archive, p.capture.Entries()); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("writing documentation")
+	return p.capture.Output()
+}
+
+func runProxy(args []string) error {
+	var (
+		config proxyConfig
+		flags  = flag.NewFlagSet("proxy", flag.ExitOnError)
+		listen = flags.String("listen", ":8080", "address to listen on")
+	)
+	flags.StringVar(&config.Upstream, "upstream", "", "url of the upstream service to proxy to")
+	flags.StringVar(&config.Outputs, "output", "plaintext", "outputs to write on shutdown, see betwixt.Parse")
+	flags.StringVar(&config.Session, "session", "", "session file to append every captured request to")
+	flags.StringVar(&config.Archive, "har", "", "HAR file to write every captured request to on shutdown")
+	flags.Int64Var(&config.Limit, "limit", betwixt.DefaultCaptureLimit, "bytes of each request and response body to capture")
+	flags.IntVar(&config.Sample, "sample", 0, "entries to keep for each endpoint, 0 keeps every entry")
+	flags.Int64Var(&config.Budget, "budget", 0, "bytes of entries to keep in memory, 0 for no budget")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: betwixt proxy -upstream <url> [flags]\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if len(config.Upstream) < 1 {
+		flags.Usage()
+	}
+	p, err := newProxy(config)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:    *listen,
+		Handler: p,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("proxying %s to %s", *listen, config.Upstream)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		if p.session != nil {
+			p.session.Close()
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdown); err != nil {
+		return err
+	}
+	return p.Close()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProxy(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"path":"` + r.URL.Path + `","forwarded":"` + r.Header.Get("X-Forwarded-Host") + `"}`))
+	}))
+	defer upstream.Close()
+
+	var (
+		dir      = t.TempDir()
+		docs     = filepath.Join(dir, "api.md")
+		archive  = filepath.Join(dir, "api.har")
+		sessions = filepath.Join(dir, "session.jsonl")
+	)
+	p, err := newProxy(proxyConfig{
+		Upstream: upstream.URL,
+		Outputs:  "markdown,file:" + docs,
+		Session:  sessions,
+		Archive:  archive,
+		Limit:    1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(p)
+	for _, v := range []string{"/users", "/orders"} {
+		resp, err := http.Get(server.URL + v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var res struct {
+			Path      string `json:"path"`
+			Forwarded string `json:"forwarded"`
+		}
+		if err := json.Unmarshal(body, &res); err != nil {
+			t.Fatal(err)
+		}
+		if expected, actual := v, res.Path; expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+		if expected, actual := strings.TrimPrefix(server.URL, "http://"), res.Forwarded; expected != actual {
+			t.Errorf("expected: %q, actual: %q", expected, actual)
+		}
+	}
+	server.Close()
+
+	// Nothing is written until the proxy is shut down.
+	if _, err := os.Stat(archive); !os.IsNotExist(err) {
+		t.Errorf("expected no archive before shutdown, actual: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for file, expected := range map[string][]string{
+		docs:     {"# GET /users", "# GET /orders"},
+		archive:  {`"url": "` + server.URL + `/users"`, `"url": "` + server.URL + `/orders"`},
+		sessions: {`"url":"/users"`, `"url":"/orders"`},
+	} {
+		bytes, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range expected {
+			if !strings.Contains(string(bytes), v) {
+				t.Errorf("expected %q in %s:\n%s", v, filepath.Base(file), bytes)
+			}
+		}
+	}
+}
+
+func TestProxyUpstream(t *testing.T) {
+	t.Parallel()
+
+	for _, upstream := range []string{"", "localhost:3000", "/api", "http://%zz"} {
+		if _, err := newProxy(proxyConfig{Upstream: upstream, Outputs: "plaintext"}); err == nil {
+			t.Errorf("%q expected error", upstream)
+		}
+	}
+}
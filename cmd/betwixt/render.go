@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/SimonRichardson/betwixt"
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+	"github.com/SimonRichardson/betwixt/pkg/har"
+	"github.com/SimonRichardson/betwixt/pkg/session"
+)
+
+func runRender(args []string) error {
+	var (
+		flags   = flag.NewFlagSet("render", flag.ExitOnError)
+		outputs = flags.String("output", "plaintext", "outputs to write, see betwixt.Parse")
+	)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: betwixt render [flags] <session>...\n\n")
+		fmt.Fprintf(flags.Output(), "All the sessions are merged into a single set of documents. Files ending\n")
+		fmt.Fprintf(flags.Output(), "in .har are read as HTTP Archives, for example those exported by a browser.\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	paths, err := expandPaths(flags.Args())
+	if err != nil {
+		return err
+	}
+	if len(paths) < 1 {
+		flags.Usage()
+		return fmt.Errorf("expected at least one session file")
+	}
+
+	entries, err := readEntries(paths)
+	if err != nil {
+		return err
+	}
+
+	out, err := betwixt.Parse(*outputs)
+	if err != nil {
+		return err
+	}
+
+	capture := betwixt.New(nil, out)
+	capture.Add(entries...)
+	return capture.Output()
+}
+
+// readEntries reads the entries from all the files, using the extension to
+// tell HAR files apart from sessions.
+func readEntries(paths []string) ([]entry.Entry, error) {
+	var res []entry.Entry
+	for _, path := range paths {
+		read := session.ReadFile
+		if strings.ToLower(filepath.Ext(path)) == ".har" {
+			read = har.ReadFile
+		}
+		entries, err := read(path)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, entries...)
+	}
+	return res, nil
+}
+
+// expandPaths expands any glob patterns, for shells that don't do it for us.
+func expandPaths(args []string) ([]string, error) {
+	var res []string
+	for _, v := range args {
+		if !strings.ContainsAny(v, "*?[") {
+			res = append(res, v)
+			continue
+		}
+		matches, err := filepath.Glob(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) < 1 {
+			return nil, fmt.Errorf("no session files match %q", v)
+		}
+		res = append(res, matches...)
+	}
+	return res, nil
+}
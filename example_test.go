@@ -13,7 +13,7 @@ import (
 	"github.com/SimonRichardson/betwixt/pkg/output"
 )
 
-func Example_Plaintext() {
+func Example_plaintext() {
 	handler := http.NewServeMux()
 	handler.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("content-type", "application/json")
@@ -61,7 +61,7 @@ func Example_Plaintext() {
 	//   {"hello":"world"}
 }
 
-func Example_Markdown() {
+func Example_markdown() {
 	handler := http.NewServeMux()
 	handler.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("content-type", "application/json")
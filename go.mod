@@ -0,0 +1,3 @@
+module github.com/SimonRichardson/betwixt
+
+go 1.23.0
@@ -0,0 +1,105 @@
+package betwixt
+
+import (
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+	"github.com/SimonRichardson/betwixt/pkg/session"
+)
+
+// Option defines a way to configure a Betwixt when it's created
+type Option func(*Betwixt)
+
+// WithStatusFilter only captures the requests where the response status
+// passes the filter. By default all statuses are captured.
+func WithStatusFilter(fn func(int) bool) Option {
+	return func(b *Betwixt) {
+		b.status = fn
+	}
+}
+
+// AnyStatus is a status filter that captures every status
+func AnyStatus(int) bool {
+	return true
+}
+
+// SuccessfulStatus is a status filter that only captures 2xx statuses
+func SuccessfulStatus(status int) bool {
+	return status >= 200 && status < 300
+}
+
+// StatusRange creates a status filter that captures statuses between min and
+// max inclusively
+func StatusRange(min, max int) func(int) bool {
+	return func(status int) bool {
+		return status >= min && status <= max
+	}
+}
+
+// WithCaptureLimit sets the amount of bytes of each request and response body
+// that are captured, anything beyond that is replaced with the
+// TruncationMarker. The handler and the client always receive the whole body.
+func WithCaptureLimit(limit int64) Option {
+	return func(b *Betwixt) {
+		b.limit = limit
+	}
+}
+
+// WithSampling keeps at most perKey entries for each endpoint, sampled
+// uniformly from all the requests, so long running captures don't keep every
+// request in memory. By default every entry is kept.
+func WithSampling(perKey int) Option {
+	return func(b *Betwixt) {
+		b.store.sample = perKey
+	}
+}
+
+// WithMemoryBudget bounds the estimated size, in bytes, of all the entries
+// held in memory. When it's exceeded, entries are discarded from the
+// endpoints with the most entries first. By default there's no budget.
+func WithMemoryBudget(bytes int64) Option {
+	return func(b *Betwixt) {
+		b.store.budget = bytes
+	}
+}
+
+// WithSession persists every captured entry to the session as it arrives, so
+// the documentation can be rendered again later.
+func WithSession(w *session.Writer) Option {
+	return func(b *Betwixt) {
+		b.session = w
+	}
+}
+
+// WithRedactor removes sensitive data from every captured entry, before it's
+// persisted or documented. See the redact package for the rules available.
+func WithRedactor(r Redactor) Option {
+	return func(b *Betwixt) {
+		b.redact = r
+	}
+}
+
+// WithRouteResolver groups the entries by the route patterns the resolver gets
+// from the router, rather than by the inferred templates. Requests that didn't
+// match a route still have their templates inferred.
+func WithRouteResolver(r RouteResolver) Option {
+	return func(b *Betwixt) {
+		b.routes = r
+	}
+}
+
+// WithThresholds sets the presence score, between 0 and 1, at which values
+// are common to every request rather than optional, for the parameters,
+// headers and body fields. By default values have to be present in every
+// request to be common.
+func WithThresholds(thresholds entry.Thresholds) Option {
+	return func(b *Betwixt) {
+		b.thresholds = thresholds
+	}
+}
+
+// WithMerge merges the values of the optional parameters and headers, rather
+// than only keeping the values with the highest score.
+func WithMerge(merge bool) Option {
+	return func(b *Betwixt) {
+		b.merge = merge
+	}
+}
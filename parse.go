@@ -9,36 +9,122 @@ import (
 	"strings"
 
 	"github.com/SimonRichardson/betwixt/pkg/output"
+	"github.com/SimonRichardson/betwixt/pkg/output/snippet"
 )
 
-// Parse a string to a possible set of outputs
+// Parse a string to a possible set of outputs. Only the keywords are case
+// insensitive, so paths and titles keep their case.
 func Parse(value string) ([]Output, error) {
 	var res []Output
-	for _, v := range strings.Split(strings.ToLower(value), ";") {
-		switch parts := strings.Split(v, ","); parts[0] {
+	for _, v := range strings.Split(value, ";") {
+		parts := strings.Split(v, ",")
+		switch keyword, arg := getKeyword(parts[0]); keyword {
 		case "plaintext":
 			out, err := getOutput(parts)
 			if err != nil {
 				return []Output{}, err
 			}
-			res = append(res, output.NewPlaintext(out))
+			languages, err := getSnippets(parts)
+			if err != nil {
+				return []Output{}, err
+			}
+			res = append(res, output.NewPlaintextWithOptions(out, output.PlaintextOptions{
+				Snippets: languages,
+				Presence: hasOption(parts, "presence"),
+				Stats:    hasOption(parts, "stats"),
+			}))
 		case "markdown":
 			out, err := getOutput(parts)
 			if err != nil {
 				return []Output{}, err
 			}
-			res = append(res, output.NewMarkdown(out, getMarkdownOptions(parts)))
+			languages, err := getSnippets(parts)
+			if err != nil {
+				return []Output{}, err
+			}
+			options := getMarkdownOptions(parts)
+			options.Snippets = languages
+			options.OmitTimestamp = hasOption(parts, "notimestamp")
+			options.Presence = hasOption(parts, "presence")
+			options.Stats = hasOption(parts, "stats")
+			res = append(res, output.NewMarkdown(out, options))
+		case "openapi":
+			out, err := getOutput(parts)
+			if err != nil {
+				return []Output{}, err
+			}
+			res = append(res, output.NewOpenAPI(out, getOpenAPIOptions(parts)))
+		case "swagger2":
+			out, err := getOutput(parts)
+			if err != nil {
+				return []Output{}, err
+			}
+			res = append(res, output.NewSwagger2(out, getOpenAPIOptions(parts)))
+		case "json":
+			out, err := getOutput(parts)
+			if err != nil {
+				return []Output{}, err
+			}
+			res = append(res, output.NewJSON(out))
+		case "blueprint":
+			out, err := getOutput(parts)
+			if err != nil {
+				return []Output{}, err
+			}
+			res = append(res, output.NewBlueprint(out, output.BlueprintOptions{
+				Name:          getOpenAPIOptions(parts).Title,
+				OmitTimestamp: hasOption(parts, "notimestamp"),
+			}))
+		case "postman":
+			out, err := getOutput(parts)
+			if err != nil {
+				return []Output{}, err
+			}
+			res = append(res, output.NewPostman(out, getOpenAPIOptions(parts).Title))
+		case "template":
+			tmpl, err := output.ParseTemplateFile(arg)
+			if err != nil {
+				return []Output{}, err
+			}
+			out, err := getOutput(parts)
+			if err != nil {
+				return []Output{}, err
+			}
+			res = append(res, output.NewTemplate(out, tmpl))
+		case "html":
+			dir, err := getDirectory(parts)
+			if err != nil {
+				return []Output{}, err
+			}
+			languages, err := getSnippets(parts)
+			if err != nil {
+				return []Output{}, err
+			}
+			res = append(res, output.NewHTML(dir, output.HTMLOptions{
+				Title:         getOpenAPIOptions(parts).Title,
+				Snippets:      languages,
+				OmitTimestamp: hasOption(parts, "notimestamp"),
+			}))
 		}
 	}
 	return res, nil
 }
 
+// getKeyword splits the keyword from its argument, for example
+// "template:api.tmpl".
+func getKeyword(part string) (string, string) {
+	if index := strings.Index(part, ":"); index >= 0 {
+		return strings.ToLower(part[:index]), part[index+1:]
+	}
+	return strings.ToLower(part), ""
+}
+
 func getOutput(parts []string) (io.WriteCloser, error) {
 	if len(parts) < 2 {
 		return os.Stdout, nil
 	}
 
-	switch value := strings.Split(parts[1], ":"); value[0] {
+	switch value := strings.Split(parts[1], ":"); strings.ToLower(value[0]) {
 	case "stdout":
 		return os.Stdout, nil
 	case "file":
@@ -65,15 +151,29 @@ func getOutput(parts []string) (io.WriteCloser, error) {
 	return nil, fmt.Errorf("no valid output found")
 }
 
+func getDirectory(parts []string) (string, error) {
+	if len(parts) < 2 {
+		return filepath.Abs("docs")
+	}
+
+	switch value := strings.Split(parts[1], ":"); strings.ToLower(value[0]) {
+	case "dir":
+		if len(value) == 2 {
+			return filepath.Abs(value[1])
+		}
+	}
+	return "", fmt.Errorf("no valid directory found")
+}
+
 func getMarkdownOptions(parts []string) output.Options {
 	if len(parts) < 3 {
 		return output.Options{}
 	}
 
-	switch value := parts[2]; value {
+	switch value := parts[2]; strings.ToLower(value) {
 	case "apiary":
 		name := "Apiary"
-		if len(parts) > 3 {
+		if len(parts) > 3 && !isOption(parts[3]) {
 			name = parts[3]
 		}
 		return output.NewApiaryOptions(name)
@@ -81,3 +181,43 @@ func getMarkdownOptions(parts []string) output.Options {
 
 	return output.Options{}
 }
+
+// getSnippets returns the snippet languages from a "snippets:" part, for
+// example "snippets:curl|python".
+func getSnippets(parts []string) ([]snippet.Language, error) {
+	for _, v := range parts[1:] {
+		if keyword, arg := getKeyword(v); keyword == "snippets" {
+			return snippet.ParseLanguages(arg)
+		}
+	}
+	return nil, nil
+}
+
+// hasOption returns true if any of the parts after the output is the option
+func hasOption(parts []string, option string) bool {
+	for _, v := range parts[1:] {
+		if strings.ToLower(v) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isOption returns true if the part is an option rather than a value, such as
+// a title.
+func isOption(part string) bool {
+	keyword, _ := getKeyword(part)
+	switch keyword {
+	case "snippets", "notimestamp", "presence", "stats":
+		return true
+	}
+	return false
+}
+
+func getOpenAPIOptions(parts []string) output.OpenAPIOptions {
+	name := "API"
+	if len(parts) > 2 && len(parts[2]) > 0 && !isOption(parts[2]) {
+		name = parts[2]
+	}
+	return output.NewOpenAPIOptions(name)
+}
@@ -0,0 +1,427 @@
+// Package diff compares two sets of documents, for example the committed
+// capture and the current test run, and reports the changes between them,
+// classifying each change as breaking or non-breaking for existing clients.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// Kind defines the kind of a Change
+type Kind string
+
+// The kinds of changes that are reported
+const (
+	EndpointAdded            Kind = "endpoint-added"
+	EndpointRemoved          Kind = "endpoint-removed"
+	StatusAdded              Kind = "status-added"
+	StatusRemoved            Kind = "status-removed"
+	ParamAdded               Kind = "param-added"
+	ParamRequired            Kind = "param-required"
+	HeaderAdded              Kind = "header-added"
+	HeaderRequired           Kind = "header-required"
+	ResponseFieldAdded       Kind = "response-field-added"
+	ResponseFieldRemoved     Kind = "response-field-removed"
+	ResponseFieldTypeChanged Kind = "response-field-type-changed"
+	ContentTypeChanged       Kind = "content-type-changed"
+)
+
+// Change is a single difference between the old and the new documents
+type Change struct {
+	Kind     Kind
+	Breaking bool
+	Method   string
+	Path     string
+	Status   int
+	Message  string
+}
+
+func (c Change) String() string {
+	endpoint := fmt.Sprintf("%s %s", c.Method, c.Path)
+	if c.Status > 0 {
+		endpoint = fmt.Sprintf("%s %d", endpoint, c.Status)
+	}
+	return fmt.Sprintf("%s: %s", endpoint, c.Message)
+}
+
+// Compare returns all the changes from the before documents to the after
+// documents, breaking changes are sorted first. Endpoints are matched by their
+// method and path, ignoring the host, so captures against different servers
+// can be compared.
+func Compare(before, after []entry.Document) []Change {
+	var (
+		res       []Change
+		oldPoints = groupEndpoints(before)
+		newPoints = groupEndpoints(after)
+	)
+	for _, key := range sortedKeys(oldPoints, newPoints) {
+		a, inOld := oldPoints[key]
+		b, inNew := newPoints[key]
+		switch {
+		case !inNew:
+			res = append(res, Change{
+				Kind:     EndpointRemoved,
+				Breaking: true,
+				Method:   a.method,
+				Path:     a.path,
+				Message:  "endpoint was removed",
+			})
+		case !inOld:
+			res = append(res, Change{
+				Kind:    EndpointAdded,
+				Method:  b.method,
+				Path:    b.path,
+				Message: "endpoint was added",
+			})
+		default:
+			res = append(res, compareEndpoints(a, b)...)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Breaking && !res[j].Breaking
+	})
+	return res
+}
+
+// Breaking returns only the breaking changes
+func Breaking(changes []Change) []Change {
+	var res []Change
+	for _, v := range changes {
+		if v.Breaking {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// endpoint holds all the documents for a method and path, by their status
+type endpoint struct {
+	method string
+	path   string
+	docs   map[int]entry.Document
+}
+
+func groupEndpoints(docs []entry.Document) map[string]endpoint {
+	res := make(map[string]endpoint, 0)
+	for _, v := range docs {
+		var (
+			method = v.Method.String()
+			path   = v.Path()
+			key    = method + " " + path
+		)
+		point, ok := res[key]
+		if !ok {
+			point = endpoint{
+				method: method,
+				path:   path,
+				docs:   make(map[int]entry.Document, 0),
+			}
+			res[key] = point
+		}
+		point.docs[v.Status.Union().Status] = v
+	}
+	return res
+}
+
+func compareEndpoints(a, b endpoint) []Change {
+	var res []Change
+	change := func(kind Kind, breaking bool, status int, format string, args ...interface{}) {
+		res = append(res, Change{
+			Kind:     kind,
+			Breaking: breaking,
+			Method:   b.method,
+			Path:     b.path,
+			Status:   status,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// A successful status that's no longer returned breaks clients expecting
+	// it, error statuses may just not have been exercised by the new capture.
+	for _, status := range sortedStatuses(a.docs, b.docs) {
+		_, inOld := a.docs[status]
+		_, inNew := b.docs[status]
+		switch {
+		case !inNew:
+			change(StatusRemoved, status >= 200 && status < 300, status, "status is no longer returned")
+		case !inOld:
+			change(StatusAdded, false, status, "status is now returned")
+		}
+	}
+
+	params := func(doc entry.Document) *entry.Map { return doc.Params }
+	for _, v := range compareRequirements(a, b, params, isQueryParam) {
+		if v.required {
+			change(ParamRequired, true, 0, "query parameter %q is now required", v.name)
+		} else {
+			change(ParamAdded, false, 0, "optional query parameter %q was added", v.name)
+		}
+	}
+
+	headers := func(doc entry.Document) *entry.Map { return doc.ReqHeaders }
+	for _, v := range compareRequirements(a, b, headers, isContractHeader) {
+		if v.required {
+			change(HeaderRequired, true, 0, "request header %q is now required", v.name)
+		} else {
+			change(HeaderAdded, false, 0, "optional request header %q was added", v.name)
+		}
+	}
+
+	if x, y := requestContentType(a), requestContentType(b); len(x) > 0 && len(y) > 0 && x != y {
+		change(ContentTypeChanged, true, 0, "request content type changed from %q to %q", x, y)
+	}
+
+	for _, status := range sortedStatuses(a.docs, b.docs) {
+		x, inOld := a.docs[status]
+		y, inNew := b.docs[status]
+		if !inOld || !inNew {
+			continue
+		}
+
+		if from, to := contentType(x.RespHeaders), contentType(y.RespHeaders); len(from) > 0 && len(to) > 0 && from != to {
+			change(ContentTypeChanged, true, status, "response content type changed from %q to %q", from, to)
+		}
+
+		var (
+			oldFields = flattenSchema(x.RespSchema.JSONSchema())
+			newFields = flattenSchema(y.RespSchema.JSONSchema())
+			parents   []string
+		)
+		for _, name := range sortedFields(oldFields, newFields) {
+			// The fields of an added or removed object aren't reported again.
+			if hasParent(parents, name) {
+				continue
+			}
+
+			from, inOld := oldFields[name]
+			to, inNew := newFields[name]
+			switch {
+			case !inNew:
+				parents = append(parents, name)
+				change(ResponseFieldRemoved, true, status, "response field %q was removed", name)
+			case !inOld:
+				parents = append(parents, name)
+				change(ResponseFieldAdded, false, status, "response field %q was added", name)
+			case !sameTypes(from, to):
+				// Only new types break clients, removing a type narrows
+				// what they already handle.
+				change(ResponseFieldTypeChanged, !containsTypes(from, to), status,
+					"response field %q changed type from %s to %s", name, strings.Join(from, "|"), strings.Join(to, "|"))
+			}
+		}
+	}
+
+	return res
+}
+
+type requirement struct {
+	name     string
+	required bool
+}
+
+// compareRequirements returns the values that are required by the new
+// endpoint but weren't by the old, along with the optional values that were
+// added.
+func compareRequirements(a, b endpoint, fn func(entry.Document) *entry.Map, filter func(string) bool) []requirement {
+	var (
+		oldRequired, oldAll = requirements(a, fn)
+		newRequired, newAll = requirements(b, fn)
+		names               []string
+	)
+	for k := range newAll {
+		if filter(k) {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	var res []requirement
+	for _, k := range names {
+		switch {
+		case newRequired[k] && !oldRequired[k]:
+			res = append(res, requirement{k, true})
+		case !oldAll[k]:
+			res = append(res, requirement{k, false})
+		}
+	}
+	return res
+}
+
+// requirements returns the keys that are present in every entry of every
+// document of the endpoint, whatever their values, along with all the keys.
+func requirements(e endpoint, fn func(entry.Document) *entry.Map) (map[string]bool, map[string]bool) {
+	var (
+		required = make(map[string]bool, 0)
+		all      = make(map[string]bool, 0)
+	)
+	for _, doc := range e.docs {
+		m := fn(doc)
+		for k := range m.Union().Values {
+			all[k] = true
+		}
+		for _, v := range m.Difference() {
+			for k := range v.Values {
+				all[k] = true
+			}
+		}
+	}
+	for k := range all {
+		required[k] = true
+		for _, doc := range e.docs {
+			if fn(doc).Presence(k) < 1 {
+				required[k] = false
+				break
+			}
+		}
+	}
+	return required, all
+}
+
+func isQueryParam(name string) bool {
+	return strings.Index(name, ":") != 0
+}
+
+// isContractHeader returns false for the headers set by the clients and
+// proxies, as they change between captures without affecting the contract.
+func isContractHeader(name string) bool {
+	return !entry.IsClientHeader(name)
+}
+
+// flattenSchema returns the types of every field of the schema, by their path,
+// for example "user.name" or "items[].id".
+func flattenSchema(schema *entry.JSONSchema) map[string][]string {
+	res := make(map[string][]string, 0)
+	var walk func(prefix string, schema *entry.JSONSchema)
+	walk = func(prefix string, schema *entry.JSONSchema) {
+		if schema == nil {
+			return
+		}
+		if len(prefix) > 0 {
+			res[prefix] = []string(schema.Type)
+		}
+		for k, v := range schema.Properties {
+			name := k
+			if len(prefix) > 0 {
+				name = prefix + "." + k
+			}
+			walk(name, v)
+		}
+		if schema.Items != nil {
+			walk(prefix+"[]", schema.Items)
+		}
+	}
+	walk("", schema)
+	return res
+}
+
+func hasParent(parents []string, name string) bool {
+	for _, v := range parents {
+		if strings.HasPrefix(name, v+".") || strings.HasPrefix(name, v+"[]") {
+			return true
+		}
+	}
+	return false
+}
+
+func sameTypes(a, b []string) bool {
+	return containsTypes(a, b) && containsTypes(b, a)
+}
+
+// containsTypes returns true if every type of b is in a
+func containsTypes(a, b []string) bool {
+	for _, x := range b {
+		found := false
+		for _, y := range a {
+			if x == y {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// requestContentType returns the content type of the first document of the
+// endpoint with a request body.
+func requestContentType(e endpoint) string {
+	for _, status := range sortedStatuses(e.docs) {
+		if doc := e.docs[status]; len(doc.ReqBody.String()) > 0 {
+			return contentType(doc.ReqHeaders)
+		}
+	}
+	return ""
+}
+
+// contentType returns the media type of the common content type header,
+// without any parameters.
+func contentType(headers *entry.Map) string {
+	var res string
+	headers.Union().Values.Walk(func(k string, v interface{}) {
+		if strings.ToLower(k) == "content-type" {
+			res = entry.ToStrings(v).Join()
+		}
+	})
+	if index := strings.Index(res, ";"); index >= 0 {
+		res = res[:index]
+	}
+	return strings.ToLower(strings.TrimSpace(res))
+}
+
+func sortedKeys(maps ...map[string]endpoint) []string {
+	var (
+		res  []string
+		seen = make(map[string]bool, 0)
+	)
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				res = append(res, k)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func sortedStatuses(maps ...map[int]entry.Document) []int {
+	var (
+		res  []int
+		seen = make(map[int]bool, 0)
+	)
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				res = append(res, k)
+			}
+		}
+	}
+	sort.Ints(res)
+	return res
+}
+
+func sortedFields(maps ...map[string][]string) []string {
+	var (
+		res  []string
+		seen = make(map[string]bool, 0)
+	)
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				res = append(res, k)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,179 @@
+package diff
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+type exchange struct {
+	method      string
+	url         string
+	status      int
+	headers     http.Header
+	contentType string
+	body        string
+}
+
+// documents groups the exchanges into a document for each method, URL and
+// status, like a capture does.
+func documents(exchanges ...exchange) []entry.Document {
+	var (
+		keys   []string
+		groups = make(map[string]entry.Entries, 0)
+	)
+	for _, v := range exchanges {
+		u, err := url.Parse(v.url)
+		if err != nil {
+			panic(err)
+		}
+		var (
+			body        = []byte(v.body)
+			respHeaders = make(http.Header, 0)
+			reqHeaders  = v.headers
+			key         = v.method + " " + u.Host + u.Path + " " + strconv.Itoa(v.status)
+		)
+		if reqHeaders == nil {
+			reqHeaders = make(http.Header, 0)
+		}
+		reqHeaders.Set("User-Agent", "test")
+		if len(v.contentType) > 0 {
+			respHeaders.Set("Content-Type", v.contentType)
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], entry.Entry{
+			URL:         u,
+			Method:      v.method,
+			Status:      v.status,
+			ReqHeaders:  reqHeaders,
+			ReqBody:     func() []byte { return nil },
+			RespHeaders: respHeaders,
+			RespBody:    func() []byte { return body },
+		})
+	}
+
+	var res []entry.Document
+	for _, k := range keys {
+		res = append(res, groups[k].Document(entry.DefaultThresholds, false))
+	}
+	return res
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	users := exchange{"GET", "http://old.example.com/users", 200, nil, "application/json", `{"id":1,"email":"a@b.c","address":{"city":"x"}}`}
+
+	for name, v := range map[string]struct {
+		before, after []exchange
+		expected      []string
+	}{
+		"unchanged": {
+			before: []exchange{users},
+			// The host is ignored, so captures of different servers match.
+			after: []exchange{{"GET", "http://new.example.com/users", 200, nil, "application/json", `{"id":2,"email":"d@e.f","address":{"city":"y"}}`}},
+		},
+		"endpoints": {
+			before: []exchange{users},
+			after:  []exchange{{"GET", "http://new.example.com/orders", 200, nil, "", ""}},
+			expected: []string{
+				"true endpoint-removed GET /users: endpoint was removed",
+				"false endpoint-added GET /orders: endpoint was added",
+			},
+		},
+		"fields": {
+			before: []exchange{users},
+			after:  []exchange{{"GET", "http://old.example.com/users", 200, nil, "application/json", `{"id":"1","name":"a"}`}},
+			expected: []string{
+				`true response-field-removed GET /users 200: response field "address" was removed`,
+				`true response-field-removed GET /users 200: response field "email" was removed`,
+				`true response-field-type-changed GET /users 200: response field "id" changed type from integer to string`,
+				`false response-field-added GET /users 200: response field "name" was added`,
+			},
+		},
+		"requirements": {
+			before: []exchange{users},
+			after: []exchange{{"GET", "http://old.example.com/users?page=1", 200, http.Header{
+				"X-Api-Key": []string{"secret"},
+			}, "application/json", users.body}},
+			expected: []string{
+				`true param-required GET /users: query parameter "page" is now required`,
+				`true header-required GET /users: request header "X-Api-Key" is now required`,
+			},
+		},
+		"varying requirements": {
+			before: []exchange{users},
+			// Every request has the values, they just differ between requests.
+			after: []exchange{
+				{"GET", "http://old.example.com/users?page=1", 200, http.Header{
+					"Authorization": []string{"Bearer a"},
+				}, "application/json", users.body},
+				{"GET", "http://old.example.com/users?page=2", 200, http.Header{
+					"Authorization": []string{"Bearer b"},
+				}, "application/json", users.body},
+				{"GET", "http://old.example.com/users?page=3", 200, http.Header{
+					"Authorization": []string{"Bearer c"},
+				}, "application/json", users.body},
+			},
+			expected: []string{
+				`true param-required GET /users: query parameter "page" is now required`,
+				`true header-required GET /users: request header "Authorization" is now required`,
+			},
+		},
+		"optional requirements": {
+			before: []exchange{users},
+			after: []exchange{
+				{"GET", "http://old.example.com/users?page=1", 200, nil, "application/json", users.body},
+				{"GET", "http://old.example.com/users", 200, nil, "application/json", users.body},
+			},
+			expected: []string{
+				`false param-added GET /users: optional query parameter "page" was added`,
+			},
+		},
+		"statuses": {
+			before: []exchange{users, {"GET", "http://old.example.com/users", 404, nil, "", ""}},
+			after:  []exchange{{"GET", "http://old.example.com/users", 201, nil, "text/plain", "ok"}},
+			expected: []string{
+				"true status-removed GET /users 200: status is no longer returned",
+				"false status-added GET /users 201: status is now returned",
+				"false status-removed GET /users 404: status is no longer returned",
+			},
+		},
+		"content type": {
+			before: []exchange{users},
+			after:  []exchange{{"GET", "http://old.example.com/users", 200, nil, "application/xml; charset=utf-8", ""}},
+			expected: []string{
+				`true content-type-changed GET /users 200: response content type changed from "application/json" to "application/xml"`,
+				`true response-field-removed GET /users 200: response field "address" was removed`,
+				`true response-field-removed GET /users 200: response field "email" was removed`,
+				`true response-field-removed GET /users 200: response field "id" was removed`,
+			},
+		},
+	} {
+		var actual []string
+		for _, c := range Compare(documents(v.before...), documents(v.after...)) {
+			actual = append(actual, strings.Join([]string{strconv.FormatBool(c.Breaking), string(c.Kind), c.String()}, " "))
+		}
+		if expected, actual := strings.Join(v.expected, "\n"), strings.Join(actual, "\n"); expected != actual {
+			t.Errorf("%s expected: \n%s\nactual: \n%s", name, expected, actual)
+		}
+	}
+}
+
+func TestBreaking(t *testing.T) {
+	t.Parallel()
+
+	changes := []Change{
+		{Kind: EndpointAdded},
+		{Kind: EndpointRemoved, Breaking: true},
+	}
+	if expected, actual := 1, len(Breaking(changes)); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+}
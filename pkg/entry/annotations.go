@@ -0,0 +1,83 @@
+package entry
+
+import "sort"
+
+// Annotations describe an endpoint in prose, they're attached to the requests
+// by the tests that make them.
+type Annotations struct {
+	Summary     string
+	Description string
+	Tags        []string
+}
+
+// Empty returns true if there are no annotations
+func (a Annotations) Empty() bool {
+	return len(a.Summary) < 1 && len(a.Description) < 1 && len(a.Tags) < 1
+}
+
+// Annotations returns the most common summary and description of all the
+// entries, along with every tag.
+func (e Entries) Annotations() Annotations {
+	var (
+		summaries    = NewString()
+		descriptions = NewString()
+		tags         [][]string
+	)
+	for _, v := range e {
+		if len(v.Annotations.Summary) > 0 {
+			summaries.Add(v.Annotations.Summary)
+		}
+		if len(v.Annotations.Description) > 0 {
+			descriptions.Add(v.Annotations.Description)
+		}
+		tags = append(tags, v.Annotations.Tags)
+	}
+
+	var res Annotations
+	if summaries.Len() > 0 {
+		res.Summary = summaries.Union().String
+	}
+	if descriptions.Len() > 0 {
+		res.Description = descriptions.Union().String
+	}
+	res.Tags = mergeTags(tags...)
+	return res
+}
+
+// MergeAnnotations merges the annotations of multiple documents, the first
+// summary and description are used, along with every tag.
+func MergeAnnotations(annotations ...Annotations) Annotations {
+	var (
+		res  Annotations
+		tags [][]string
+	)
+	for _, v := range annotations {
+		if len(res.Summary) < 1 {
+			res.Summary = v.Summary
+		}
+		if len(res.Description) < 1 {
+			res.Description = v.Description
+		}
+		tags = append(tags, v.Tags)
+	}
+	res.Tags = mergeTags(tags...)
+	return res
+}
+
+// mergeTags returns the sorted, unique tags
+func mergeTags(tags ...[]string) []string {
+	var (
+		res  []string
+		seen = make(map[string]bool, 0)
+	)
+	for _, v := range tags {
+		for _, tag := range v {
+			if len(tag) > 0 && !seen[tag] {
+				seen[tag] = true
+				res = append(res, tag)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
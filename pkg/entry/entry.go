@@ -5,25 +5,40 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+	"time"
 )
 
 // Score is a type alias for a score, which is modelled as float64
 type Score float64
 
-// Entry defines a raw http request.
+// Entry defines a raw http request. Requests received by a server have a
+// relative URL, the Origin holds the scheme and host they were received on.
 type Entry struct {
 	URL         *url.URL
+	Origin      string
+	Template    string
+	Started     time.Time
+	Duration    time.Duration
 	Method      string
 	Status      int
 	ReqHeaders  http.Header
 	ReqBody     func() []byte
 	RespHeaders http.Header
 	RespBody    func() []byte
+	ReqSize     int64
+	RespSize    int64
+	Annotations Annotations
 }
 
-// NormalisePath attempts to normalise both a Host and Path in a sane way
+// NormalisePath attempts to normalise both a Host and Path in a sane way. If
+// the entry has a Template, then that's used in place of the Path.
 func (e Entry) NormalisePath() string {
+	if len(e.Template) > 0 {
+		return fmt.Sprintf("%s%s", e.URL.Host, e.Template)
+	}
+
 	path := fmt.Sprintf("%s%s", e.URL.Host, e.URL.Path)
 	for k, v := range e.URL.Query() {
 		if strings.Index(k, ":") == 0 {
@@ -36,6 +51,21 @@ func (e Entry) NormalisePath() string {
 	return path
 }
 
+// AbsoluteURL returns the URL of the entry, resolved against the Origin if the
+// URL is relative.
+func (e Entry) AbsoluteURL() *url.URL {
+	if e.URL.IsAbs() || len(e.Origin) < 1 {
+		return e.URL
+	}
+	origin, err := url.Parse(e.Origin)
+	if err != nil {
+		return e.URL
+	}
+	res := *e.URL
+	res.Scheme, res.Host = origin.Scheme, origin.Host
+	return &res
+}
+
 // Entries is a type alias for a slice of Entry
 type Entries []Entry
 
@@ -55,12 +85,34 @@ func (e Entries) URL() *URL {
 	for _, v := range e {
 		u.Add(HostPath{
 			Host: v.URL.Host,
-			Path: v.NormalisePath(),
+			Path: strings.TrimPrefix(v.NormalisePath(), v.URL.Host),
 		})
 	}
 	return u
 }
 
+// Scheme returns a String of all the schemes of the URLs, which is empty for
+// relative URLs.
+func (e Entries) Scheme() *String {
+	s := NewString()
+	for _, v := range e {
+		s.Add(v.URL.Scheme)
+	}
+	return s
+}
+
+// Origin returns a String of all the origins of the entries, which is empty
+// for absolute URLs.
+func (e Entries) Origin() *String {
+	s := NewString()
+	for _, v := range e {
+		if !v.URL.IsAbs() {
+			s.Add(v.Origin)
+		}
+	}
+	return s
+}
+
 // Method returns a String of all possible methods.
 func (e Entries) Method() *String {
 	m := NewString()
@@ -81,7 +133,10 @@ func (e Entries) Status() *Status {
 
 // Params returns a Map of all possible http parameters
 func (e Entries) Params() *Map {
-	p := NewMap()
+	return e.params(NewMap())
+}
+
+func (e Entries) params(p *Map) *Map {
 	for _, v := range e {
 		var (
 			values = make(ValuesPromoted, 0)
@@ -94,6 +149,13 @@ func (e Entries) Params() *Map {
 				Promoted: isURLKey(url.Path, k, v),
 			}
 		}
+		for k, v := range v.PathParams() {
+			bytes, _ := json.Marshal([]string{v})
+			values[k] = ValuePromoted{
+				Value:    string(bytes),
+				Promoted: true,
+			}
+		}
 		p.Add(values)
 	}
 	return p
@@ -101,7 +163,10 @@ func (e Entries) Params() *Map {
 
 // ReqHeaders returns a Map of all possible http request headers
 func (e Entries) ReqHeaders() *Map {
-	p := NewMap()
+	return e.reqHeaders(NewMap())
+}
+
+func (e Entries) reqHeaders(p *Map) *Map {
 	for _, v := range e {
 		values := make(ValuesPromoted, 0)
 		for k, v := range v.ReqHeaders {
@@ -124,9 +189,24 @@ func (e Entries) ReqBody() *String {
 	return m
 }
 
+// ReqSchema returns a Schema inferred from all the JSON http request bodies
+func (e Entries) ReqSchema() *Schema {
+	return e.reqSchema(NewSchema())
+}
+
+func (e Entries) reqSchema(s *Schema) *Schema {
+	for _, v := range e {
+		s.Add(v.ReqBody())
+	}
+	return s
+}
+
 // RespHeaders returns a Map of all possible http response headers
 func (e Entries) RespHeaders() *Map {
-	p := NewMap()
+	return e.respHeaders(NewMap())
+}
+
+func (e Entries) respHeaders(p *Map) *Map {
 	for _, v := range e {
 		values := make(ValuesPromoted, 0)
 		for k, v := range v.RespHeaders {
@@ -149,14 +229,42 @@ func (e Entries) RespBody() *String {
 	return m
 }
 
+// RespSchema returns a Schema inferred from all the JSON http response bodies
+func (e Entries) RespSchema() *Schema {
+	return e.respSchema(NewSchema())
+}
+
+func (e Entries) respSchema(s *Schema) *Schema {
+	for _, v := range e {
+		s.Add(v.RespBody())
+	}
+	return s
+}
+
+// Stats returns the Stats of the durations and sizes of all the entries
+func (e Entries) Stats() *Stats {
+	s := NewStats()
+	for _, v := range e {
+		s.Add(v.Duration, v.ReqSize, v.RespSize)
+	}
+	return s
+}
+
 // GroupedEntries allows the grouping of all entries for a specific key
 type GroupedEntries map[string][]Entry
 
-// Walk allows the walking over of each Entry returning a Document
+// Walk allows the walking over of each Entry returning a Document, the groups
+// are walked in the order of their keys.
 func (g GroupedEntries) Walk(fn func(Entries) (Document, error)) ([]Document, error) {
+	keys := make([]string, 0, len(g))
+	for k := range g {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
 	res := make([]Document, 0, len(g))
-	for _, v := range g {
-		o, err := fn(Entries(v))
+	for _, k := range keys {
+		o, err := fn(Entries(g[k]))
 		if err != nil {
 			return nil, err
 		}
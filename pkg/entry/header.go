@@ -0,0 +1,27 @@
+package entry
+
+import "strings"
+
+// clientHeaders are set by the HTTP clients and proxies a request passes
+// through, rather than by the caller, so they aren't part of an API's
+// contract.
+var clientHeaders = map[string]bool{
+	"accept-encoding":   true,
+	"connection":        true,
+	"content-length":    true,
+	"host":              true,
+	"keep-alive":        true,
+	"proxy-connection":  true,
+	"te":                true,
+	"transfer-encoding": true,
+	"user-agent":        true,
+	"x-forwarded-for":   true,
+	"x-forwarded-host":  true,
+	"x-forwarded-proto": true,
+}
+
+// IsClientHeader returns true if the header is set by the HTTP client or a
+// proxy, for example the hop-by-hop headers or Accept-Encoding.
+func IsClientHeader(name string) bool {
+	return clientHeaders[strings.ToLower(name)]
+}
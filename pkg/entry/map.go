@@ -69,7 +69,14 @@ type Map struct {
 
 // NewMap creates a Map with some default sane values.
 func NewMap() *Map {
-	return &Map{make(map[Value]*ScorePromoted, 0), 0, 1.0, false}
+	return NewMapWithThreshold(1.0, false)
+}
+
+// NewMapWithThreshold creates a Map where values with a score of at least the
+// threshold are common. If merge is true, then the values of the differences
+// are merged together.
+func NewMapWithThreshold(threshold Score, merge bool) *Map {
+	return &Map{make(map[Value]*ScorePromoted, 0), 0, threshold, merge}
 }
 
 // Add adds ValuesPromoted to the map
@@ -93,6 +100,21 @@ func (p *Map) Len() int {
 	return p.total
 }
 
+// Presence returns the score of entries that have the key, regardless of its
+// value.
+func (p *Map) Presence(key string) Score {
+	if p.total < 1 {
+		return 0
+	}
+	var count Score
+	for k, v := range p.values {
+		if k.Key == key {
+			count += v.Score
+		}
+	}
+	return count / Score(float64(p.total))
+}
+
 // Union returns the common ValuesScore of the map.
 func (p *Map) Union() ValuesScore {
 	scored := make(map[Value]Score, 0)
@@ -107,11 +129,23 @@ func (p *Map) Union() ValuesScore {
 		scored[k] = score / total
 	}
 
-	values := make(Values, 0)
-	for k, v := range scored {
-		if v >= p.threshold {
-			values[k.Key] = k.Value
+	// When a key has more than one value above the threshold, the highest
+	// scoring value is chosen. Values are visited in order, so ties always
+	// choose the lowest value.
+	var (
+		values = make(Values, 0)
+		best   = make(map[string]Score, 0)
+	)
+	for _, k := range p.sortedValues() {
+		score := scored[k]
+		if score < p.threshold {
+			continue
+		}
+		if _, ok := values[k.Key]; ok && score <= best[k.Key] {
+			continue
 		}
+		values[k.Key] = k.Value
+		best[k.Key] = score
 	}
 
 	return ValuesScore{
@@ -129,13 +163,13 @@ func (p *Map) Difference() []ValuesScore {
 		values = make(map[Score]Values, 0)
 	)
 
-	for k, v := range p.values {
+	for _, k := range p.sortedValues() {
 		// Remove if it's already found in unique
 		if _, ok := common.Values[k.Key]; ok {
 			continue
 		}
 
-		score := v.Score
+		score := p.values[k].Score
 		if _, ok := values[score]; !ok {
 			values[score] = make(Values, 0)
 		}
@@ -178,6 +212,22 @@ func (p *Map) Difference() []ValuesScore {
 	return res
 }
 
+// sortedValues returns every Value sorted by key and then value, so that
+// iterating the map is predictable.
+func (p *Map) sortedValues() []Value {
+	res := make([]Value, 0, len(p.values))
+	for k := range p.values {
+		res = append(res, k)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Key != res[j].Key {
+			return res[i].Key < res[j].Key
+		}
+		return res[i].Value < res[j].Value
+	})
+	return res
+}
+
 // ValuesScores is a type alias for sorting a slice of ValuesScore
 type ValuesScores []ValuesScore
 
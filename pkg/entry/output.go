@@ -1,14 +1,71 @@
 package entry
 
+import "strings"
+
 // Document defines a struct of all the possible things that a http request
 // could encounter.
 type Document struct {
 	Method      *String
 	Status      *Status
+	Scheme      *String
+	Origin      *String
 	URL         *URL
 	Params      *Map
 	ReqHeaders  *Map
 	ReqBody     *String
+	ReqSchema   *Schema
 	RespHeaders *Map
 	RespBody    *String
+	RespSchema  *Schema
+	Stats       *Stats
+	Annotations Annotations
+}
+
+// Path returns the path of the document without the host.
+func (d Document) Path() string {
+	hostPath := d.URL.Union().HostPath
+	path := strings.TrimPrefix(hostPath.Path, hostPath.Host)
+	if len(path) < 1 {
+		return "/"
+	}
+	return path
+}
+
+// Thresholds define the presence score, between 0 and 1, at which a value is
+// common to all the entries of a Document rather than optional. Each section
+// of the Document has its own threshold.
+type Thresholds struct {
+	Params  Score
+	Headers Score
+	Body    Score
+}
+
+// DefaultThresholds only treats values that are present in every entry as
+// common.
+var DefaultThresholds = Thresholds{
+	Params:  1.0,
+	Headers: 1.0,
+	Body:    1.0,
+}
+
+// Document returns a Document of all the entries, using the thresholds for
+// each section. If merge is true, then the values of the differences are
+// merged together.
+func (e Entries) Document(thresholds Thresholds, merge bool) Document {
+	return Document{
+		URL:         e.URL(),
+		Scheme:      e.Scheme(),
+		Origin:      e.Origin(),
+		Method:      e.Method(),
+		Status:      e.Status(),
+		Params:      e.params(NewMapWithThreshold(thresholds.Params, merge)),
+		ReqHeaders:  e.reqHeaders(NewMapWithThreshold(thresholds.Headers, merge)),
+		ReqBody:     e.ReqBody(),
+		ReqSchema:   e.reqSchema(NewSchemaWithThreshold(thresholds.Body)),
+		RespHeaders: e.respHeaders(NewMapWithThreshold(thresholds.Headers, merge)),
+		RespBody:    e.RespBody(),
+		RespSchema:  e.respSchema(NewSchemaWithThreshold(thresholds.Body)),
+		Stats:       e.Stats(),
+		Annotations: e.Annotations(),
+	}
 }
@@ -0,0 +1,247 @@
+package entry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// minCardinality is the least amount of distinct values a path segment
+	// must have before it's considered to be variable.
+	minCardinality = 3
+
+	// minCardinalityRatio is the least ratio of distinct values to total
+	// values a path segment must have before it's considered to be variable.
+	// This stops frequently used sibling resources from being collapsed.
+	minCardinalityRatio = 0.5
+)
+
+// Sibling routes, such as "/api/health" and "/api/status", are each usually
+// requested once by a test suite, whereas the values of a variable segment are
+// requested repeatedly, so most of the distinct values must also repeat.
+
+var (
+	integerSegment = regexp.MustCompile(`^[0-9]+$`)
+	uuidSegment    = regexp.MustCompile(`^(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	hexSegment     = regexp.MustCompile(`^(?i)[0-9a-f]{8,}$`)
+	dateSegment    = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+	digitSegment   = regexp.MustCompile(`[0-9]`)
+)
+
+// InferTemplates detects variable path segments (integers, UUIDs, hex ids,
+// dates and high cardinality strings) across all the entries, returning a copy
+// of the entries with a Template set for each path that contains them.
+func (e Entries) InferTemplates() Entries {
+	paths := make([][]string, len(e))
+	kinds := make([][]string, len(e))
+	for k, v := range e {
+		paths[k] = splitPath(strings.TrimPrefix(v.NormalisePath(), v.URL.Host))
+		kinds[k] = make([]string, len(paths[k]))
+		for i, segment := range paths[k] {
+			kinds[k][i] = segmentKind(segment)
+		}
+	}
+
+	inferCardinality(paths, kinds)
+
+	res := make(Entries, len(e))
+	for k, v := range e {
+		res[k] = v
+		// Templates resolved from the router are always kept.
+		if len(v.Template) > 0 {
+			continue
+		}
+		if template, ok := buildTemplate(paths[k], kinds[k]); ok {
+			res[k].Template = template
+		}
+	}
+	return res
+}
+
+// PathParams returns the values of the path segments that are variable in the
+// Template of the entry.
+func (e Entry) PathParams() map[string]string {
+	res := make(map[string]string, 0)
+	if len(e.Template) < 1 {
+		return res
+	}
+
+	var (
+		template = splitPath(e.Template)
+		path     = splitPath(e.URL.Path)
+	)
+	if len(template) != len(path) {
+		return res
+	}
+	for k, v := range template {
+		if strings.Index(v, ":") == 0 && strings.Index(path[k], ":") != 0 {
+			res[v] = path[k]
+		}
+	}
+	return res
+}
+
+// RouteTemplate converts the pattern of a router's route into a Template, so
+// "GET /users/{id}", "/users/{id:[0-9]+}" and "/users/:id" all become
+// "/users/:id". Any method or host in the pattern is removed, wildcards become
+// ":path" and "{$}" is dropped.
+func RouteTemplate(pattern string) string {
+	// Go 1.22 http.ServeMux patterns can start with a method and a host.
+	if index := strings.Index(pattern, " "); index >= 0 {
+		pattern = strings.TrimSpace(pattern[index:])
+	}
+	if index := strings.Index(pattern, "/"); index > 0 {
+		pattern = pattern[index:]
+	}
+
+	segments := splitPath(pattern)
+	for k, v := range segments {
+		switch {
+		case v == "{$}":
+			segments[k] = ""
+		case v == "*":
+			segments[k] = ":path"
+		case strings.HasPrefix(v, "{") && strings.HasSuffix(v, "}"):
+			name := strings.TrimSuffix(v[1:len(v)-1], "...")
+			if index := strings.Index(name, ":"); index >= 0 {
+				name = name[:index]
+			}
+			segments[k] = ":" + name
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// SampleKey returns a key that entries for the same endpoint are likely to
+// share, before the templates have been inferred. A Template resolved from the
+// router is used as is, otherwise segments that look like ids or dates are
+// replaced, so they aren't treated as separate endpoints.
+func (e Entry) SampleKey() string {
+	if len(e.Template) > 0 {
+		return fmt.Sprintf("%s %s%s %d", e.Method, e.URL.Host, e.Template, e.Status)
+	}
+	segments := splitPath(e.URL.Path)
+	for k, v := range segments {
+		if len(segmentKind(v)) > 0 {
+			segments[k] = "*"
+		}
+	}
+	return fmt.Sprintf("%s %s/%s %d", e.Method, e.URL.Host, strings.Join(segments, "/"), e.Status)
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+func segmentKind(segment string) string {
+	switch {
+	case len(segment) < 1, strings.Index(segment, ":") == 0:
+		return ""
+	case integerSegment.MatchString(segment),
+		uuidSegment.MatchString(segment),
+		hexSegment.MatchString(segment) && digitSegment.MatchString(segment):
+		return "id"
+	case dateSegment.MatchString(segment):
+		return "date"
+	}
+	return ""
+}
+
+// inferCardinality marks segments as variable when the same position in
+// otherwise matching paths has lots of distinct values.
+func inferCardinality(paths, kinds [][]string) {
+	type cardinality struct {
+		values map[string]int
+		total  int
+	}
+
+	key := func(k, position int) string {
+		segments := make([]string, len(paths[k]))
+		for i, v := range paths[k] {
+			if i == position || len(kinds[k][i]) > 0 {
+				v = "*"
+			}
+			segments[i] = v
+		}
+		return strings.Join(segments, "/")
+	}
+	candidate := func(k, position int) bool {
+		segment := paths[k][position]
+		return position > 0 && len(kinds[k][position]) < 1 &&
+			len(segment) > 0 && strings.Index(segment, ":") != 0
+	}
+
+	groups := make(map[string]*cardinality, 0)
+	for k := range paths {
+		for i := range paths[k] {
+			if !candidate(k, i) {
+				continue
+			}
+			id := key(k, i)
+			if _, ok := groups[id]; !ok {
+				groups[id] = &cardinality{values: make(map[string]int, 0)}
+			}
+			groups[id].values[paths[k][i]]++
+			groups[id].total++
+		}
+	}
+
+	variable := make(map[string]bool, 0)
+	for k, v := range groups {
+		var (
+			distinct = len(v.values)
+			repeated int
+		)
+		for _, hits := range v.values {
+			if hits > 1 {
+				repeated++
+			}
+		}
+		variable[k] = distinct >= minCardinality &&
+			float64(distinct)/float64(v.total) >= minCardinalityRatio &&
+			repeated*2 > distinct
+	}
+
+	for k := range paths {
+		for i := range paths[k] {
+			if candidate(k, i) && variable[key(k, i)] {
+				kinds[k][i] = "slug"
+			}
+		}
+	}
+}
+
+// buildTemplate names each variable segment, disambiguating names that are
+// used more than once by prefixing them with the preceding literal segment.
+func buildTemplate(path, kinds []string) (string, bool) {
+	counts := make(map[string]int, 0)
+	for _, v := range kinds {
+		if len(v) > 0 {
+			counts[v]++
+		}
+	}
+	if len(counts) < 1 {
+		return "", false
+	}
+
+	segments := make([]string, len(path))
+	for k, v := range path {
+		kind := kinds[k]
+		if len(kind) < 1 {
+			segments[k] = v
+			continue
+		}
+
+		name := kind
+		if counts[kind] > 1 {
+			if k > 0 && len(kinds[k-1]) < 1 {
+				name = fmt.Sprintf("%s_%s", strings.TrimSuffix(path[k-1], "s"), kind)
+			} else {
+				name = fmt.Sprintf("%s%d", kind, k)
+			}
+		}
+		segments[k] = ":" + name
+	}
+	return "/" + strings.Join(segments, "/"), true
+}
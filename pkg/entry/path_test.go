@@ -0,0 +1,110 @@
+package entry
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestRouteTemplate(t *testing.T) {
+	t.Parallel()
+
+	for pattern, expected := range map[string]string{
+		"/users":                         "/users",
+		"/users/{id}":                    "/users/:id",
+		"GET /users/{id}/orders/{order}": "/users/:id/orders/:order",
+		"POST example.com/users/{id}":    "/users/:id",
+		"/files/{path...}":               "/files/:path",
+		"/users/{$}":                     "/users/",
+		"/users/{id:[0-9]+}":             "/users/:id",
+		"/codes/{code:[a-z]{3}}":         "/codes/:code",
+		"/users/:id/orders/:order":       "/users/:id/orders/:order",
+		"/static/*":                      "/static/:path",
+		"/":                              "/",
+	} {
+		if actual := RouteTemplate(pattern); expected != actual {
+			t.Errorf("%q expected: %q, actual: %q", pattern, expected, actual)
+		}
+	}
+}
+
+func TestInferTemplates(t *testing.T) {
+	t.Parallel()
+
+	entries := func(paths ...string) Entries {
+		var res Entries
+		for _, v := range paths {
+			u, err := url.Parse(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res = append(res, Entry{URL: u, Method: "GET", Status: 200})
+		}
+		return res
+	}
+
+	for name, v := range map[string]struct {
+		paths, expected []string
+	}{
+		"ids": {
+			paths:    []string{"/users/1", "/users/2", "/users/3f2504e0-4f89-11d3-9a0c-0305e82c3301/posts/2024-01-02"},
+			expected: []string{"/users/:id", "/users/:id", "/users/:id/posts/:date"},
+		},
+		// Sibling routes requested once each are separate endpoints.
+		"siblings": {
+			paths:    []string{"/api/health", "/api/status", "/api/version", "/users/me", "/users/search", "/users/export"},
+			expected: []string{"", "", "", "", "", ""},
+		},
+		// Sibling routes requested many times are still separate endpoints.
+		"frequent siblings": {
+			paths: []string{
+				"/api/health", "/api/status", "/api/version",
+				"/api/health", "/api/status", "/api/version",
+				"/api/health", "/api/status", "/api/version",
+			},
+			expected: []string{"", "", "", "", "", "", "", "", ""},
+		},
+		"slugs": {
+			paths: []string{
+				"/users/alice", "/users/bob", "/users/carol",
+				"/users/alice", "/users/bob", "/users/carol",
+			},
+			expected: []string{
+				"/users/:slug", "/users/:slug", "/users/:slug",
+				"/users/:slug", "/users/:slug", "/users/:slug",
+			},
+		},
+	} {
+		res := entries(v.paths...).InferTemplates()
+		for k, e := range res {
+			if expected, actual := v.expected[k], e.Template; expected != actual {
+				t.Errorf("%s %q expected: %q, actual: %q", name, v.paths[k], expected, actual)
+			}
+		}
+	}
+}
+
+func TestSampleKey(t *testing.T) {
+	t.Parallel()
+
+	entry := func(path, template string) Entry {
+		u, err := url.Parse(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Entry{URL: u, Method: "GET", Status: 200, Template: template}
+	}
+
+	for _, v := range []struct {
+		a, b Entry
+		same bool
+	}{
+		{a: entry("/users/1", ""), b: entry("/users/2", ""), same: true},
+		{a: entry("/users/alice", ""), b: entry("/users/bob", ""), same: false},
+		{a: entry("/users/alice", "/users/:name"), b: entry("/users/bob", "/users/:name"), same: true},
+		{a: entry("/users/1", "/users/:id"), b: entry("/users/1", "/users/:name"), same: false},
+	} {
+		if expected, actual := v.same, v.a.SampleKey() == v.b.SampleKey(); expected != actual {
+			t.Errorf("%q and %q expected same: %t, actual: %t", v.a.SampleKey(), v.b.SampleKey(), expected, actual)
+		}
+	}
+}
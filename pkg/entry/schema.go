@@ -0,0 +1,224 @@
+package entry
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+const (
+	// maxEnumValues is the largest amount of distinct strings that can be
+	// considered to be an enum.
+	maxEnumValues = 5
+)
+
+// JSONSchema defines a JSON Schema that has been inferred from a series of
+// JSON bodies.
+type JSONSchema struct {
+	Type       Types                  `json:"type,omitempty"`
+	Properties map[string]*JSONSchema `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+	Items      *JSONSchema            `json:"items,omitempty"`
+	Enum       []string               `json:"enum,omitempty"`
+}
+
+// Nullable returns true if the schema allows null values
+func (s *JSONSchema) Nullable() bool {
+	for _, v := range s.Type {
+		if v == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// Types is a type alias for a slice of JSON Schema types, which is encoded as
+// a single string when there is only one type.
+type Types []string
+
+// MarshalJSON encodes the Types as either a string or a slice of strings
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON decodes the Types from either a string or a slice of strings
+func (t *Types) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = Types{s}
+		return nil
+	}
+	var res []string
+	if err := json.Unmarshal(b, &res); err != nil {
+		return err
+	}
+	*t = Types(res)
+	return nil
+}
+
+// Schema infers a merged JSON Schema from all the JSON bodies added to it.
+// Properties are required if their occurrence score is at least the threshold
+// score.
+type Schema struct {
+	root      *schemaNode
+	total     int
+	threshold Score
+}
+
+// NewSchema creates a Schema with some default sane values.
+func NewSchema() *Schema {
+	return NewSchemaWithThreshold(1.0)
+}
+
+// NewSchemaWithThreshold creates a Schema where properties with a score of at
+// least the threshold are required.
+func NewSchemaWithThreshold(threshold Score) *Schema {
+	return &Schema{newSchemaNode(), 0, threshold}
+}
+
+// Add adds a body to the schema, bodies that aren't valid JSON are ignored.
+func (s *Schema) Add(body []byte) {
+	if len(bytes.TrimSpace(body)) < 1 {
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return
+	}
+
+	s.root.add(value)
+	s.total++
+}
+
+// Len returns the total number of JSON bodies added
+func (s *Schema) Len() int {
+	return s.total
+}
+
+// JSONSchema returns the inferred JSON Schema, or nil if no JSON bodies have
+// been added.
+func (s *Schema) JSONSchema() *JSONSchema {
+	if s.total < 1 {
+		return nil
+	}
+	return s.root.schema(s.threshold)
+}
+
+type schemaNode struct {
+	types      map[string]int
+	objects    int
+	properties map[string]*schemaNode
+	items      *schemaNode
+	strings    map[string]int
+	enumerable bool
+}
+
+func newSchemaNode() *schemaNode {
+	return &schemaNode{
+		types:      make(map[string]int, 0),
+		properties: make(map[string]*schemaNode, 0),
+		strings:    make(map[string]int, 0),
+		enumerable: true,
+	}
+}
+
+func (n *schemaNode) add(value interface{}) {
+	switch t := value.(type) {
+	case nil:
+		n.types["null"]++
+	case bool:
+		n.types["boolean"]++
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			n.types["integer"]++
+		} else {
+			n.types["number"]++
+		}
+	case string:
+		n.types["string"]++
+		if n.enumerable {
+			n.strings[t]++
+			if len(n.strings) > maxEnumValues {
+				n.enumerable = false
+				n.strings = nil
+			}
+		}
+	case []interface{}:
+		n.types["array"]++
+		if n.items == nil {
+			n.items = newSchemaNode()
+		}
+		for _, v := range t {
+			n.items.add(v)
+		}
+	case map[string]interface{}:
+		n.types["object"]++
+		n.objects++
+		for k, v := range t {
+			property, ok := n.properties[k]
+			if !ok {
+				property = newSchemaNode()
+				n.properties[k] = property
+			}
+			property.add(v)
+		}
+	}
+}
+
+func (n *schemaNode) count() (res int) {
+	for _, v := range n.types {
+		res += v
+	}
+	return
+}
+
+func (n *schemaNode) schema(threshold Score) *JSONSchema {
+	res := &JSONSchema{}
+
+	types := make([]string, 0, len(n.types))
+	for k := range n.types {
+		// Integers are a subset of numbers, so favour the wider type.
+		if k == "integer" && n.types["number"] > 0 {
+			continue
+		}
+		types = append(types, k)
+	}
+	sort.Strings(types)
+	res.Type = Types(types)
+
+	if n.objects > 0 {
+		res.Properties = make(map[string]*JSONSchema, len(n.properties))
+		for k, v := range n.properties {
+			res.Properties[k] = v.schema(threshold)
+
+			// A property that is present with a null value still counts
+			// towards it being required.
+			if Score(float64(v.count()))/Score(float64(n.objects)) >= threshold {
+				res.Required = append(res.Required, k)
+			}
+		}
+		sort.Strings(res.Required)
+	}
+
+	if n.items != nil && n.items.count() > 0 {
+		res.Items = n.items.schema(threshold)
+	}
+
+	// Only infer an enum when there's a choice of values that are repeated,
+	// otherwise every low-cardinality sample would be an enum.
+	if n.enumerable && len(n.strings) > 1 && n.types["string"] > len(n.strings) && n.types["string"] == n.count() {
+		for k := range n.strings {
+			res.Enum = append(res.Enum, k)
+		}
+		sort.Strings(res.Enum)
+	}
+
+	return res
+}
@@ -0,0 +1,47 @@
+package entry
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	t.Run("merge", func(t *testing.T) {
+		schema := NewSchema()
+		schema.Add([]byte(`{"id":1,"name":"a","state":"on","tags":["x"],"meta":{"age":1.5}}`))
+		schema.Add([]byte(`{"id":2,"state":"off","tags":[],"meta":{"age":2}}`))
+		schema.Add([]byte(`{"id":3,"name":null,"state":"on","meta":{"age":3}}`))
+		schema.Add([]byte(`not json`))
+
+		if expected, actual := 3, schema.Len(); expected != actual {
+			t.Errorf("expected: %d, actual: %d", expected, actual)
+		}
+
+		bytes, err := json.Marshal(schema.JSONSchema())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body := `{"type":"object","properties":{` +
+			`"id":{"type":"integer"},` +
+			`"meta":{"type":"object","properties":{"age":{"type":"number"}},"required":["age"]},` +
+			`"name":{"type":["null","string"]},` +
+			`"state":{"type":"string","enum":["off","on"]},` +
+			`"tags":{"type":"array","items":{"type":"string"}}` +
+			`},"required":["id","meta","state"]}`
+		if expected, actual := body, string(bytes); expected != actual {
+			t.Errorf("expected: \n%s\n, actual: \n%s\n", expected, actual)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		schema := NewSchema()
+		schema.Add(nil)
+
+		if actual := schema.JSONSchema(); actual != nil {
+			t.Errorf("expected: nil, actual: %v", actual)
+		}
+	})
+}
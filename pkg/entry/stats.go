@@ -0,0 +1,91 @@
+package entry
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Stats holds the latency and payload sizes of all the entries of a Document
+type Stats struct {
+	durations []time.Duration
+	reqSize   int64
+	respSize  int64
+}
+
+// NewStats creates a Stats with sane defaults
+func NewStats() *Stats {
+	return &Stats{}
+}
+
+// Add adds the duration and sizes of a single entry
+func (s *Stats) Add(duration time.Duration, reqSize, respSize int64) {
+	// Keep the durations sorted, so the percentiles can be read at any time.
+	index := sort.Search(len(s.durations), func(i int) bool {
+		return s.durations[i] >= duration
+	})
+	s.durations = append(s.durations, 0)
+	copy(s.durations[index+1:], s.durations[index:])
+	s.durations[index] = duration
+
+	s.reqSize += reqSize
+	s.respSize += respSize
+}
+
+// Len returns the number of entries added
+func (s *Stats) Len() int {
+	return len(s.durations)
+}
+
+// Min returns the shortest duration
+func (s *Stats) Min() time.Duration {
+	return s.Percentile(0)
+}
+
+// Max returns the longest duration
+func (s *Stats) Max() time.Duration {
+	return s.Percentile(100)
+}
+
+// P50 returns the median duration
+func (s *Stats) P50() time.Duration {
+	return s.Percentile(50)
+}
+
+// P95 returns the duration that 95% of the entries completed within
+func (s *Stats) P95() time.Duration {
+	return s.Percentile(95)
+}
+
+// Percentile returns the duration at the percentile, between 0 and 100, using
+// the nearest rank.
+func (s *Stats) Percentile(p float64) time.Duration {
+	if len(s.durations) < 1 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(s.durations))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(s.durations) {
+		rank = len(s.durations)
+	}
+	return s.durations[rank-1]
+}
+
+// AvgReqSize returns the average size of the request bodies in bytes
+func (s *Stats) AvgReqSize() float64 {
+	return s.average(s.reqSize)
+}
+
+// AvgRespSize returns the average size of the response bodies in bytes
+func (s *Stats) AvgRespSize() float64 {
+	return s.average(s.respSize)
+}
+
+func (s *Stats) average(total int64) float64 {
+	if len(s.durations) < 1 {
+		return 0
+	}
+	return float64(total) / float64(len(s.durations))
+}
@@ -0,0 +1,41 @@
+package entry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	stats := NewStats()
+	for _, v := range []int{7, 3, 10, 1, 5, 9, 2, 8, 4, 6} {
+		stats.Add(time.Duration(v)*time.Millisecond, int64(v), int64(v*10))
+	}
+
+	for name, v := range map[string]struct {
+		expected, actual time.Duration
+	}{
+		"min": {time.Millisecond, stats.Min()},
+		"p50": {5 * time.Millisecond, stats.P50()},
+		"p95": {10 * time.Millisecond, stats.P95()},
+		"max": {10 * time.Millisecond, stats.Max()},
+	} {
+		if v.expected != v.actual {
+			t.Errorf("%s expected: %s, actual: %s", name, v.expected, v.actual)
+		}
+	}
+	if expected, actual := 10, stats.Len(); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := 5.5, stats.AvgReqSize(); expected != actual {
+		t.Errorf("expected: %f, actual: %f", expected, actual)
+	}
+	if expected, actual := 55.0, stats.AvgRespSize(); expected != actual {
+		t.Errorf("expected: %f, actual: %f", expected, actual)
+	}
+
+	if expected, actual := time.Duration(0), NewStats().P95(); expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
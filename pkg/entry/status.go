@@ -1,6 +1,9 @@
 package entry
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 type StatusScore struct {
 	Status int
@@ -28,7 +31,7 @@ func (m *Status) Len() int {
 func (m *Status) Union() StatusScore {
 	common := StatusScore{0, 0.0}
 	for k, v := range m.values {
-		if v != common.Score {
+		if v > common.Score || v == common.Score && k < common.Status {
 			common.Status = k
 			common.Score = v
 		}
@@ -53,6 +56,13 @@ func (m *Status) Difference() []StatusScore {
 		})
 	}
 
+	sort.Slice(alt, func(i, j int) bool {
+		if alt[i].Score != alt[j].Score {
+			return alt[i].Score > alt[j].Score
+		}
+		return alt[i].Status < alt[j].Status
+	})
+
 	return alt
 }
 
@@ -1,5 +1,7 @@
 package entry
 
+import "sort"
+
 // StringScore is a tuple containing a string and a score
 type StringScore struct {
 	String string
@@ -28,11 +30,12 @@ func (m *String) Len() int {
 	return m.total
 }
 
-// Union returns the most common string
+// Union returns the most common string, ties are broken by choosing the
+// lowest string.
 func (m *String) Union() StringScore {
 	common := StringScore{"", 0.0}
 	for k, v := range m.values {
-		if v > common.Score {
+		if v > common.Score || v == common.Score && k < common.String {
 			common.String = k
 			common.Score = v
 		}
@@ -41,7 +44,8 @@ func (m *String) Union() StringScore {
 	return common
 }
 
-// Difference returns a slice of StringScores that doesn't equal the Union.
+// Difference returns a slice of StringScores that doesn't equal the Union,
+// sorted by the highest score first.
 func (m *String) Difference() []StringScore {
 	var (
 		common = m.Union()
@@ -58,6 +62,13 @@ func (m *String) Difference() []StringScore {
 		})
 	}
 
+	sort.Slice(alt, func(i, j int) bool {
+		if alt[i].Score != alt[j].Score {
+			return alt[i].Score > alt[j].Score
+		}
+		return alt[i].String < alt[j].String
+	})
+
 	return alt
 }
 
@@ -1,6 +1,9 @@
 package entry
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 // HostPath is a tuple of both the Host and the Path
 type HostPath struct {
@@ -42,11 +45,12 @@ func (u *URL) Len() int {
 	return u.total
 }
 
-// Union returns the most common HostPathScore
+// Union returns the most common HostPathScore, ties are broken by choosing the
+// lowest HostPath.
 func (u *URL) Union() HostPathScore {
 	common := HostPathScore{HostPath{}, 0}
 	for k, v := range u.values {
-		if v > common.Score {
+		if v > common.Score || v == common.Score && k.String() < common.HostPath.String() {
 			common.HostPath = k
 			common.Score = v
 		}
@@ -56,7 +60,8 @@ func (u *URL) Union() HostPathScore {
 	return common
 }
 
-// Difference returns all the HostPathScore's that aren't the most common.
+// Difference returns all the HostPathScore's that aren't the most common,
+// sorted by the highest score first.
 func (u *URL) Difference() []HostPathScore {
 	var (
 		common = u.Union()
@@ -73,6 +78,13 @@ func (u *URL) Difference() []HostPathScore {
 		})
 	}
 
+	sort.Slice(alt, func(i, j int) bool {
+		if alt[i].Score != alt[j].Score {
+			return alt[i].Score > alt[j].Score
+		}
+		return alt[i].HostPath.String() < alt[j].HostPath.String()
+	})
+
 	return alt
 }
 
@@ -0,0 +1,380 @@
+// Package har reads and writes captured entries as HTTP Archive (HAR) 1.2
+// files, so traffic recorded by browsers or other proxies can be documented,
+// and betwixt captures can be loaded into existing HAR viewers.
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// Version is the version of the HAR specification that is written
+const Version = "1.2"
+
+// creator identifies betwixt as the application that wrote the HAR document
+var creator = harCreator{Name: "betwixt", Version: "1"}
+
+type archive struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	// Annotations is a custom field, which other tools ignore.
+	Annotations *harAnnotations `json:"_annotations,omitempty"`
+}
+
+type harAnnotations struct {
+	Summary     string   `json:"summary,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []harCookie  `json:"cookies"`
+	Headers     []harNameVal `json:"headers"`
+	QueryString []harNameVal `json:"queryString"`
+	PostData    *harPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []harCookie  `json:"cookies"`
+	Headers     []harNameVal `json:"headers"`
+	Content     harContent   `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Write writes all the entries as a single HAR document
+func Write(w io.Writer, entries []entry.Entry) error {
+	doc := archive{
+		Log: harLog{
+			Version: Version,
+			Creator: creator,
+			Entries: make([]harEntry, 0, len(entries)),
+		},
+	}
+	for _, v := range entries {
+		doc.Log.Entries = append(doc.Log.Entries, newHAREntry(v))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// WriteFile writes all the entries to a HAR file at path, replacing it if it
+// already exists.
+func WriteFile(path string, entries []entry.Entry) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(file, entries); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Read reads all the entries from a HAR document. Requests that never received
+// a response, which browsers record with a status of 0, are skipped.
+func Read(r io.Reader) ([]entry.Entry, error) {
+	var doc archive
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var res []entry.Entry
+	for k, v := range doc.Log.Entries {
+		if v.Response.Status == 0 {
+			continue
+		}
+		e, err := v.entry()
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %v", k, err)
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+// ReadFile reads all the entries from a HAR file
+func ReadFile(path string) ([]entry.Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	res, err := Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return res, nil
+}
+
+// ReadFiles reads and merges all the entries from multiple HAR files
+func ReadFiles(paths ...string) ([]entry.Entry, error) {
+	var res []entry.Entry
+	for _, path := range paths {
+		entries, err := ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, entries...)
+	}
+	return res, nil
+}
+
+// defaultOrigin is used for entries without an origin, as HAR requires
+// absolute URLs.
+const defaultOrigin = "http://localhost"
+
+func absoluteURL(e entry.Entry) string {
+	if len(e.Origin) < 1 {
+		e.Origin = defaultOrigin
+	}
+	return e.AbsoluteURL().String()
+}
+
+func newHAREntry(e entry.Entry) harEntry {
+	var (
+		reqBody  = e.ReqBody()
+		respBody = e.RespBody()
+	)
+
+	// The whole of the duration is spent waiting for the response, as the
+	// time spent sending and receiving isn't recorded separately.
+	duration := milliseconds(e.Duration)
+
+	res := harEntry{
+		StartedDateTime: e.Started,
+		Time:            duration,
+		Request: harRequest{
+			Method:      e.Method,
+			URL:         absoluteURL(e),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     make([]harCookie, 0),
+			Headers:     newNameVals(e.ReqHeaders),
+			QueryString: newNameVals(e.URL.Query()),
+			HeadersSize: -1,
+			BodySize:    bodySize(e.ReqSize, reqBody),
+		},
+		Response: harResponse{
+			Status:      e.Status,
+			StatusText:  http.StatusText(e.Status),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     make([]harCookie, 0),
+			Headers:     newNameVals(e.RespHeaders),
+			Content: harContent{
+				Size:     bodySize(e.RespSize, respBody),
+				MimeType: e.RespHeaders.Get("Content-Type"),
+			},
+			RedirectURL: e.RespHeaders.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    bodySize(e.RespSize, respBody),
+		},
+		Timings: harTimings{
+			Wait: duration,
+		},
+	}
+	if len(reqBody) > 0 {
+		text, encoding := encodeBody(reqBody)
+		res.Request.PostData = &harPostData{
+			MimeType: e.ReqHeaders.Get("Content-Type"),
+			Text:     text,
+			Encoding: encoding,
+		}
+	}
+	res.Response.Content.Text, res.Response.Content.Encoding = encodeBody(respBody)
+
+	if a := e.Annotations; !a.Empty() {
+		res.Annotations = &harAnnotations{
+			Summary:     a.Summary,
+			Description: a.Description,
+			Tags:        a.Tags,
+		}
+	}
+
+	return res
+}
+
+func (e harEntry) entry() (entry.Entry, error) {
+	u, err := url.Parse(e.Request.URL)
+	if err != nil {
+		return entry.Entry{}, err
+	}
+
+	var reqBody []byte
+	if data := e.Request.PostData; data != nil {
+		if reqBody, err = decodeBody(data.Text, data.Encoding); err != nil {
+			return entry.Entry{}, err
+		}
+	}
+	respBody, err := decodeBody(e.Response.Content.Text, e.Response.Content.Encoding)
+	if err != nil {
+		return entry.Entry{}, err
+	}
+
+	var annotations entry.Annotations
+	if a := e.Annotations; a != nil {
+		annotations = entry.Annotations{
+			Summary:     a.Summary,
+			Description: a.Description,
+			Tags:        a.Tags,
+		}
+	}
+
+	respSize := e.Response.Content.Size
+	if respSize <= 0 {
+		respSize = e.Response.BodySize
+	}
+
+	return entry.Entry{
+		URL:        u,
+		Started:    e.StartedDateTime,
+		Duration:   time.Duration(e.Time * float64(time.Millisecond)),
+		Method:     e.Request.Method,
+		Status:     e.Response.Status,
+		ReqHeaders: headers(e.Request.Headers),
+		ReqBody: func() []byte {
+			return reqBody
+		},
+		RespHeaders: headers(e.Response.Headers),
+		RespBody: func() []byte {
+			return respBody
+		},
+		// Unknown sizes are written as -1.
+		ReqSize:     int64(max(e.Request.BodySize, 0)),
+		RespSize:    int64(max(respSize, 0)),
+		Annotations: annotations,
+	}, nil
+}
+
+// bodySize returns the size of the body that was sent, falling back to the
+// captured body for entries that didn't record it.
+func bodySize(size int64, body []byte) int {
+	if size > 0 {
+		return int(size)
+	}
+	return len(body)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func newNameVals(values map[string][]string) []harNameVal {
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	res := make([]harNameVal, 0, len(values))
+	for _, k := range names {
+		for _, v := range values[k] {
+			res = append(res, harNameVal{Name: k, Value: v})
+		}
+	}
+	return res
+}
+
+// headers converts the HAR headers into a http.Header. HTTP/2 captures use
+// lower case names along with pseudo headers, such as ":authority", which
+// aren't documented.
+func headers(values []harNameVal) http.Header {
+	res := make(http.Header, len(values))
+	for _, v := range values {
+		if strings.Index(v.Name, ":") == 0 {
+			continue
+		}
+		res.Add(v.Name, v.Value)
+	}
+	return res
+}
+
+// encodeBody returns the body as text, falling back to base64 if the body
+// isn't valid utf8.
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func decodeBody(text, encoding string) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case "":
+		return []byte(text), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(text)
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", encoding)
+}
@@ -0,0 +1,180 @@
+package har
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	var (
+		u, _    = url.Parse("http://example.org/users/1?expand=true")
+		started = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		binary  = []byte{0xff, 0xfe, 0x00}
+		e       = entry.Entry{
+			URL:     u,
+			Started: started,
+			Method:  "POST",
+			Status:  201,
+			ReqHeaders: http.Header{
+				"Content-Type": []string{"application/json"},
+			},
+			ReqBody: func() []byte {
+				return []byte(`{"name":"bob"}`)
+			},
+			RespHeaders: http.Header{
+				"Content-Type": []string{"application/octet-stream"},
+			},
+			RespBody: func() []byte {
+				return binary
+			},
+		}
+	)
+
+	var buf bytes.Buffer
+	if err := Write(&buf, []entry.Entry{e}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"encoding": "base64"`) {
+		t.Errorf("expected binary body to be base64 encoded\n%s", buf.String())
+	}
+
+	entries, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := 1, len(entries); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+
+	res := entries[0]
+	if expected, actual := u.String(), res.URL.String(); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if !started.Equal(res.Started) {
+		t.Errorf("expected: %v, actual: %v", started, res.Started)
+	}
+	if expected, actual := "POST", res.Method; expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := 201, res.Status; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := "application/json", res.ReqHeaders.Get("Content-Type"); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := `{"name":"bob"}`, string(res.ReqBody()); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := binary, res.RespBody(); !bytes.Equal(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestReadBrowser(t *testing.T) {
+	t.Parallel()
+
+	// A trimmed down HAR, as exported by a browser over HTTP/2.
+	doc := `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "WebInspector", "version": "537.36"},
+    "entries": [
+      {
+        "startedDateTime": "2020-01-02T03:04:05.678Z",
+        "time": 12.5,
+        "request": {
+          "method": "GET",
+          "url": "https://example.org/users?page=2",
+          "httpVersion": "http/2.0",
+          "headers": [
+            {"name": ":authority", "value": "example.org"},
+            {"name": "accept", "value": "application/json"}
+          ],
+          "queryString": [{"name": "page", "value": "2"}],
+          "cookies": [],
+          "headersSize": -1,
+          "bodySize": 0
+        },
+        "response": {
+          "status": 200,
+          "statusText": "",
+          "httpVersion": "http/2.0",
+          "headers": [{"name": "content-type", "value": "application/json"}],
+          "cookies": [],
+          "content": {"size": 2, "mimeType": "application/json", "text": "W10=", "encoding": "base64"},
+          "redirectURL": "",
+          "headersSize": -1,
+          "bodySize": -1
+        },
+        "cache": {},
+        "timings": {"send": 1, "wait": 10, "receive": 1.5}
+      },
+      {
+        "startedDateTime": "2020-01-02T03:04:06.000Z",
+        "time": 0,
+        "request": {"method": "GET", "url": "https://example.org/blocked", "headers": []},
+        "response": {"status": 0, "headers": [], "content": {"size": 0, "mimeType": ""}}
+      }
+    ]
+  }
+}`
+
+	entries, err := Read(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := 1, len(entries); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+
+	res := entries[0]
+	if expected, actual := "/users", res.URL.Path; expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := 1, len(res.ReqHeaders); expected != actual {
+		t.Errorf("expected pseudo headers to be skipped: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := "application/json", res.ReqHeaders.Get("Accept"); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := "[]", string(res.RespBody()); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	t.Parallel()
+
+	u, _ := url.Parse("/hello?a=1")
+	for origin, expected := range map[string]string{
+		"https://example.org": `"url": "https://example.org/hello?a=1"`,
+		"":                    `"url": "http://localhost/hello?a=1"`,
+	} {
+		e := entry.Entry{
+			URL:         u,
+			Origin:      origin,
+			Method:      "GET",
+			Status:      200,
+			ReqHeaders:  http.Header{},
+			ReqBody:     func() []byte { return nil },
+			RespHeaders: http.Header{},
+			RespBody:    func() []byte { return nil },
+		}
+
+		var buf bytes.Buffer
+		if err := Write(&buf, []entry.Entry{e}); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected: %q, actual: \n%s", expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// Blueprint renders an API Blueprint document, where documents are grouped
+// into resources by their path and actions by their method.
+type Blueprint struct {
+	w       io.WriteCloser
+	options BlueprintOptions
+}
+
+// BlueprintOptions define certain setup values for rendering API Blueprint
+type BlueprintOptions struct {
+	Name          string
+	OmitTimestamp bool
+}
+
+// NewBlueprint creates a Blueprint with the correct dependencies
+func NewBlueprint(w io.WriteCloser, options BlueprintOptions) *Blueprint {
+	return &Blueprint{w, options}
+}
+
+// Output takes a slice of documents and generates an API Blueprint document
+// from them
+func (o Blueprint) Output(docs []entry.Document) error {
+	fmt.Fprintf(o.w, "FORMAT: 1A\n\n# %s\n", o.options.Name)
+	writeGenerated(o.w, o.options.OmitTimestamp)
+
+	for _, group := range groupResources(groupOperations(docs)) {
+		fmt.Fprintf(o.w, "\n# Group %s\n", group.Name)
+
+		for _, resource := range group.Resources {
+			params := resourceParameters(resource)
+
+			fmt.Fprintf(o.w, "\n## %s [%s%s]\n", resource.Name, templatePath(resource.Path), queryTemplate(params))
+			if len(params) > 0 {
+				fmt.Fprintf(o.w, "\n+ Parameters\n")
+				for _, v := range params {
+					fmt.Fprintf(o.w, "    + %s: `%s` (%s, string)\n", v.Name, v.Example, requirement(v.Required))
+				}
+			}
+
+			for _, op := range resource.Operations {
+				annotations := op.annotations()
+				name := annotations.Summary
+				if len(name) < 1 {
+					name = fmt.Sprintf("%s %s", actionVerb(op.Method), resource.Name)
+				}
+				fmt.Fprintf(o.w, "\n### %s [%s]\n", name, op.Method)
+				if len(annotations.Description) > 0 {
+					fmt.Fprintf(o.w, "\n%s\n", annotations.Description)
+				}
+
+				for _, doc := range op.Docs {
+					name := ""
+					if len(op.Docs) > 1 {
+						name = fmt.Sprintf(" Status %d", doc.Status.Union().Status)
+					}
+					writeBlueprintRequest(o.w, name, doc)
+					writeBlueprintResponse(o.w, doc)
+				}
+			}
+		}
+	}
+
+	return o.w.Close()
+}
+
+// resourceGroup is a collection of resources that share the same first tag,
+// or the same first path segment if they don't have any tags.
+type resourceGroup struct {
+	Name      string
+	Resources []resource
+}
+
+// resource is a collection of operations that share the same path.
+type resource struct {
+	Name       string
+	Path       string
+	Operations []operation
+}
+
+func groupResources(ops []operation) []resourceGroup {
+	var (
+		res       []resourceGroup
+		groups    = make(map[string]int, 0)
+		resources = make(map[string]int, 0)
+	)
+	for _, op := range ops {
+		name := op.tag()
+		if len(name) < 1 {
+			name = groupName(op.Path)
+		}
+		index, ok := groups[name]
+		if !ok {
+			index = len(res)
+			groups[name] = index
+			res = append(res, resourceGroup{Name: name})
+		}
+
+		// Operations of the same path can be in different groups, if they
+		// have different tags.
+		group := &res[index]
+		key := name + " " + op.Path
+		resourceIndex, ok := resources[key]
+		if !ok {
+			resourceIndex = len(group.Resources)
+			resources[key] = resourceIndex
+			group.Resources = append(group.Resources, resource{
+				Name: resourceName(op.Path),
+				Path: op.Path,
+			})
+		}
+		group.Resources[resourceIndex].Operations = append(group.Resources[resourceIndex].Operations, op)
+	}
+	return res
+}
+
+func groupName(path string) string {
+	for _, v := range splitSegments(path) {
+		if strings.Index(v, ":") != 0 {
+			return title(v)
+		}
+	}
+	return "Root"
+}
+
+func resourceName(path string) string {
+	var words []string
+	for _, v := range splitSegments(path) {
+		if strings.Index(v, ":") == 0 {
+			words = append(words, "By "+title(v[1:]))
+			continue
+		}
+		words = append(words, title(v))
+	}
+	if len(words) < 1 {
+		return "Root"
+	}
+	return strings.Join(words, " ")
+}
+
+func splitSegments(path string) []string {
+	var res []string
+	for _, v := range strings.Split(path, "/") {
+		if len(v) > 0 {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func title(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	})
+	for k, v := range words {
+		words[k] = strings.ToUpper(v[:1]) + v[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+func actionVerb(method string) string {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		return "Retrieve"
+	case http.MethodPost:
+		return "Create"
+	case http.MethodPut:
+		return "Replace"
+	case http.MethodPatch:
+		return "Update"
+	case http.MethodDelete:
+		return "Delete"
+	}
+	return title(strings.ToLower(method))
+}
+
+type blueprintParameter struct {
+	Name     string
+	Example  string
+	Required bool
+	Query    bool
+}
+
+// resourceParameters returns the path and query parameters of all the
+// operations of a resource.
+func resourceParameters(r resource) []blueprintParameter {
+	var (
+		res   []blueprintParameter
+		index = make(map[string]int, 0)
+		total int
+	)
+	for _, op := range r.Operations {
+		for _, doc := range op.Docs {
+			total++
+			seen := make(map[string]bool, 0)
+			add := func(required bool) func(string, interface{}) {
+				return func(k string, v interface{}) {
+					query := strings.Index(k, ":") != 0
+					name := strings.TrimPrefix(k, ":")
+					if seen[name] {
+						return
+					}
+					seen[name] = true
+
+					if i, ok := index[name]; ok {
+						res[i].Required = res[i].Required && required
+						return
+					}
+					index[name] = len(res)
+					res = append(res, blueprintParameter{
+						Name:     name,
+						Example:  entry.ToStrings(v).Join(),
+						Required: required || !query,
+						Query:    query,
+					})
+				}
+			}
+			doc.Params.Union().Values.Walk(add(true))
+			for _, v := range doc.Params.Difference() {
+				v.Values.Walk(add(false))
+			}
+		}
+	}
+
+	// Query parameters missing from any document are optional.
+	counts := make(map[string]int, 0)
+	for _, op := range r.Operations {
+		for _, doc := range op.Docs {
+			doc.Params.Union().Values.Walk(func(k string, v interface{}) {
+				counts[strings.TrimPrefix(k, ":")]++
+			})
+		}
+	}
+	for k, v := range res {
+		if v.Query && counts[v.Name] < total {
+			res[k].Required = false
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Query != res[j].Query {
+			return !res[i].Query
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+func queryTemplate(params []blueprintParameter) string {
+	var names []string
+	for _, v := range params {
+		if v.Query {
+			names = append(names, v.Name)
+		}
+	}
+	if len(names) < 1 {
+		return ""
+	}
+	return "{?" + strings.Join(names, ",") + "}"
+}
+
+func requirement(required bool) string {
+	if required {
+		return "required"
+	}
+	return "optional"
+}
+
+func writeBlueprintRequest(w io.Writer, name string, doc entry.Document) {
+	var (
+		body        = doc.ReqBody.String()
+		schema      = doc.ReqSchema.JSONSchema()
+		contentType = getContentType(doc.ReqHeaders)
+	)
+	if doc.ReqHeaders.Len() < 1 && len(body) < 1 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n+ Request%s%s\n", name, mediaType(contentType))
+	writeBlueprintHeaders(w, doc.ReqHeaders)
+	writeBlueprintAttributes(w, schema)
+	writeBlueprintBody(w, contentType, body)
+}
+
+func writeBlueprintResponse(w io.Writer, doc entry.Document) {
+	var (
+		body        = doc.RespBody.String()
+		schema      = doc.RespSchema.JSONSchema()
+		contentType = getContentType(doc.RespHeaders)
+	)
+
+	fmt.Fprintf(w, "\n+ Response %d%s\n", doc.Status.Union().Status, mediaType(contentType))
+	writeBlueprintHeaders(w, doc.RespHeaders)
+	writeBlueprintAttributes(w, schema)
+	writeBlueprintBody(w, contentType, body)
+}
+
+func mediaType(contentType string) string {
+	if len(contentType) < 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", contentType)
+}
+
+// writeBlueprintHeaders writes the common headers, the content type is
+// already part of the request or response section.
+func writeBlueprintHeaders(w io.Writer, headers *entry.Map) {
+	var lines []string
+	headers.Union().Values.Walk(func(k string, v interface{}) {
+		if strings.ToLower(k) != "content-type" {
+			lines = append(lines, fmt.Sprintf("            %s: %s\n", k, entry.ToStrings(v).Join()))
+		}
+	})
+	if len(lines) < 1 {
+		return
+	}
+	fmt.Fprintf(w, "\n    + Headers\n\n")
+	for _, v := range lines {
+		fmt.Fprint(w, v)
+	}
+}
+
+func writeBlueprintBody(w io.Writer, contentType, body string) {
+	if len(body) < 1 {
+		return
+	}
+	fmt.Fprintf(w, "\n    + Body\n\n")
+	if isJSON(contentType) {
+		if err := writeBody(w, body); err == nil {
+			return
+		}
+	}
+	for _, v := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		fmt.Fprintf(w, "            %s\n", v)
+	}
+}
+
+// writeBlueprintAttributes writes the inferred schema as MSON attributes
+func writeBlueprintAttributes(w io.Writer, schema *entry.JSONSchema) {
+	if schema == nil {
+		return
+	}
+	fmt.Fprintf(w, "\n    + Attributes (%s)\n", msonType(schema))
+	writeMSONMembers(w, "        ", schema)
+}
+
+func writeMSONMembers(w io.Writer, indent string, schema *entry.JSONSchema) {
+	switch {
+	case len(schema.Properties) > 0:
+		names := make([]string, 0, len(schema.Properties))
+		for k := range schema.Properties {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		required := make(map[string]bool, len(schema.Required))
+		for _, v := range schema.Required {
+			required[v] = true
+		}
+
+		for _, k := range names {
+			property := schema.Properties[k]
+			attributes := []string{msonType(property), requirement(required[k])}
+			if property.Nullable() {
+				attributes = append(attributes, "nullable")
+			}
+			fmt.Fprintf(w, "%s+ %s (%s)\n", indent, k, strings.Join(attributes, ", "))
+			writeMSONMembers(w, indent+"    ", property)
+		}
+	case len(schema.Enum) > 0:
+		fmt.Fprintf(w, "%s+ Members\n", indent)
+		for _, v := range schema.Enum {
+			fmt.Fprintf(w, "%s    + `%s`\n", indent, v)
+		}
+	case schema.Items != nil && len(schema.Items.Properties) > 0:
+		fmt.Fprintf(w, "%s+ (object)\n", indent)
+		writeMSONMembers(w, indent+"    ", schema.Items)
+	}
+}
+
+func msonType(schema *entry.JSONSchema) string {
+	var types []string
+	for _, v := range schema.Type {
+		switch v {
+		case "null":
+		case "integer":
+			types = append(types, "number")
+		default:
+			types = append(types, v)
+		}
+	}
+
+	// MSON has no way to describe a mix of types, so fall back to a string.
+	if len(types) != 1 {
+		return "string"
+	}
+
+	switch t := types[0]; {
+	case len(schema.Enum) > 0:
+		return "enum[" + t + "]"
+	case t == "array" && schema.Items != nil:
+		return "array[" + msonType(schema.Items) + "]"
+	default:
+		return t
+	}
+}
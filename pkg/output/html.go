@@ -0,0 +1,400 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+	"github.com/SimonRichardson/betwixt/pkg/output/snippet"
+)
+
+// HTMLOptions define certain setup values for rendering a html site
+type HTMLOptions struct {
+	Title         string
+	Snippets      []snippet.Language
+	OmitTimestamp bool
+}
+
+// HTML renders a self-contained static html site into a directory, with an
+// index of all the endpoints and a page for each method and path.
+type HTML struct {
+	dir     string
+	options HTMLOptions
+}
+
+// NewHTML creates a HTML with the correct dependencies
+func NewHTML(dir string, options HTMLOptions) *HTML {
+	return &HTML{dir, options}
+}
+
+// Output takes a slice of documents and generates a html site from them
+func (o HTML) Output(docs []entry.Document) error {
+	if err := os.MkdirAll(o.dir, 0755); err != nil {
+		return err
+	}
+
+	var (
+		ops   = groupOperations(docs)
+		index = htmlIndex{
+			Title: o.options.Title,
+		}
+		names = make(map[string]int, 0)
+	)
+	if !o.options.OmitTimestamp {
+		index.Generated = time.Now().Format(time.RFC3339)
+	}
+	for _, group := range groupResources(ops) {
+		section := htmlSection{Name: group.Name}
+		for _, resource := range group.Resources {
+			for _, op := range resource.Operations {
+				page, err := newHTMLPage(o.options, op, pageName(names, op))
+				if err != nil {
+					return err
+				}
+				if err := writeTemplate(filepath.Join(o.dir, page.File), htmlPageTemplate, page); err != nil {
+					return err
+				}
+				section.Pages = append(section.Pages, page)
+			}
+		}
+		index.Sections = append(index.Sections, section)
+	}
+
+	return writeTemplate(filepath.Join(o.dir, "index.html"), htmlIndexTemplate, index)
+}
+
+type htmlIndex struct {
+	Title     string
+	Generated string
+	Sections  []htmlSection
+}
+
+type htmlSection struct {
+	Name  string
+	Pages []htmlPage
+}
+
+type htmlPage struct {
+	Title       string
+	File        string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Tags        []string
+	Responses   []htmlResponse
+}
+
+type htmlResponse struct {
+	Status      int
+	StatusText  string
+	Params      []htmlValue
+	ReqHeaders  []htmlValue
+	ReqBody     template.HTML
+	ReqSchema   template.HTML
+	Snippets    []htmlSnippet
+	RespHeaders []htmlValue
+	RespBody    template.HTML
+	RespSchema  template.HTML
+	Stats       []statsValue
+}
+
+type htmlSnippet struct {
+	Name string
+	Code string
+}
+
+type htmlValue struct {
+	Key      string
+	Value    string
+	Optional bool
+	Presence string
+}
+
+func newHTMLPage(options HTMLOptions, op operation, file string) (htmlPage, error) {
+	annotations := op.annotations()
+	page := htmlPage{
+		Title:       options.Title,
+		File:        file,
+		Method:      op.Method,
+		Path:        templatePath(op.Path),
+		Summary:     annotations.Summary,
+		Description: annotations.Description,
+		Tags:        annotations.Tags,
+	}
+	for _, doc := range op.Docs {
+		snippets, err := htmlSnippets(doc, options.Snippets)
+		if err != nil {
+			return page, err
+		}
+
+		status := doc.Status.Union().Status
+		page.Responses = append(page.Responses, htmlResponse{
+			Status:      status,
+			StatusText:  statusDescription(status),
+			Params:      htmlValues(doc.Params),
+			ReqHeaders:  htmlValues(doc.ReqHeaders),
+			ReqBody:     highlightBody(getContentType(doc.ReqHeaders), doc.ReqBody.String()),
+			ReqSchema:   highlightSchema(doc.ReqSchema),
+			Snippets:    snippets,
+			RespHeaders: htmlValues(doc.RespHeaders),
+			RespBody:    highlightBody(getContentType(doc.RespHeaders), doc.RespBody.String()),
+			RespSchema:  highlightSchema(doc.RespSchema),
+			Stats:       statsValues(doc.Stats),
+		})
+	}
+	return page, nil
+}
+
+func htmlSnippets(doc entry.Document, languages []snippet.Language) ([]htmlSnippet, error) {
+	var (
+		res []htmlSnippet
+		req = snippet.NewRequest(doc, snippet.DefaultBaseURL)
+	)
+	for _, l := range languages {
+		code, err := snippet.Generate(l, req)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, htmlSnippet{Name: l.Name(), Code: code})
+	}
+	return res, nil
+}
+
+func htmlValues(m *entry.Map) []htmlValue {
+	var res []htmlValue
+	m.Union().Values.Walk(func(k string, v interface{}) {
+		res = append(res, htmlValue{
+			Key:      k,
+			Value:    entry.ToStrings(v).Join(),
+			Presence: percentage(m.Presence(k)),
+		})
+	})
+	for _, v := range m.Difference() {
+		v.Values.Walk(func(k string, v interface{}) {
+			res = append(res, htmlValue{
+				Key:      k,
+				Value:    entry.ToStrings(v).Join(),
+				Optional: true,
+				Presence: percentage(m.Presence(k)),
+			})
+		})
+	}
+	return res
+}
+
+// pageName returns a unique file name for the operation
+func pageName(names map[string]int, op operation) string {
+	name := strings.ToLower(op.Method)
+	for _, v := range splitSegments(op.Path) {
+		name += "-" + strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return '_'
+		}, strings.TrimPrefix(v, ":"))
+	}
+
+	names[name]++
+	if n := names[name]; n > 1 {
+		name = fmt.Sprintf("%s-%d", name, n)
+	}
+	return name + ".html"
+}
+
+func highlightBody(contentType, body string) template.HTML {
+	if len(body) < 1 {
+		return ""
+	}
+	if isJSON(contentType) {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(body), "", "  "); err == nil {
+			return highlightJSON(buf.String())
+		}
+	}
+	return template.HTML(html.EscapeString(body))
+}
+
+func highlightSchema(schema *entry.Schema) template.HTML {
+	doc := schema.JSONSchema()
+	if doc == nil {
+		return ""
+	}
+	bytes, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return highlightJSON(string(bytes))
+}
+
+// highlightJSON wraps each token of an indented JSON document in a span, so it
+// can be styled without any client side dependencies.
+func highlightJSON(doc string) template.HTML {
+	var (
+		buf bytes.Buffer
+		pos int
+	)
+	span := func(class, token string) {
+		fmt.Fprintf(&buf, `<span class="%s">%s</span>`, class, html.EscapeString(token))
+	}
+
+	for pos < len(doc) {
+		switch c := doc[pos]; {
+		case c == '"':
+			end := pos + 1
+			for end < len(doc) && doc[end] != '"' {
+				if doc[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end++
+			if end > len(doc) {
+				end = len(doc)
+			}
+
+			class := "string"
+			if rest := strings.TrimLeft(doc[end:], " "); strings.HasPrefix(rest, ":") {
+				class = "key"
+			}
+			span(class, doc[pos:end])
+			pos = end
+		case c == '-' || c >= '0' && c <= '9':
+			end := pos + 1
+			for end < len(doc) && strings.IndexByte("0123456789.eE+-", doc[end]) >= 0 {
+				end++
+			}
+			span("number", doc[pos:end])
+			pos = end
+		case strings.HasPrefix(doc[pos:], "true"), strings.HasPrefix(doc[pos:], "null"):
+			span("literal", doc[pos:pos+4])
+			pos += 4
+		case strings.HasPrefix(doc[pos:], "false"):
+			span("literal", doc[pos:pos+5])
+			pos += 5
+		default:
+			buf.WriteString(html.EscapeString(string(c)))
+			pos++
+		}
+	}
+	return template.HTML(buf.String())
+}
+
+func writeTemplate(path string, tmpl *template.Template, data interface{}) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(file, data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+const htmlStyle = `
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0 auto; max-width: 960px; padding: 1em 2em; color: #24292e; }
+a { color: #0366d6; text-decoration: none; }
+a:hover { text-decoration: underline; }
+footer { margin-top: 3em; color: #6a737d; font-size: 0.85em; }
+input[type=search] { width: 100%; padding: 0.5em; font-size: 1em; box-sizing: border-box; }
+ul.endpoints { list-style: none; padding: 0; }
+ul.endpoints li { padding: 0.25em 0; }
+.method { display: inline-block; min-width: 5em; font-weight: bold; font-family: monospace; }
+.method.GET { color: #2cbe4e; } .method.POST { color: #0366d6; } .method.PUT, .method.PATCH { color: #e36209; } .method.DELETE { color: #cb2431; }
+.path { font-family: monospace; }
+details { margin: 0.5em 0; }
+summary { cursor: pointer; font-weight: bold; }
+table { border-collapse: collapse; margin: 0.5em 0; }
+td, th { border: 1px solid #e1e4e8; padding: 0.25em 0.75em; text-align: left; font-family: monospace; }
+.optional { color: #6a737d; }
+pre { background: #f6f8fa; padding: 1em; overflow: auto; }
+.key { color: #005cc5; } .string { color: #032f62; } .number { color: #e36209; } .literal { color: #d73a49; }
+.status { font-weight: bold; }
+.summary { color: #586069; }
+.description { white-space: pre-wrap; }
+.tag { display: inline-block; background: #f1f8ff; color: #0366d6; padding: 0 0.5em; border-radius: 1em; font-size: 0.85em; }
+`
+
+var htmlIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>` + htmlStyle + `</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<input type="search" id="search" placeholder="Search endpoints" autofocus>
+{{range .Sections}}
+<section>
+<h2>{{.Name}}</h2>
+<ul class="endpoints">
+{{range .Pages}}<li><a href="{{.File}}"><span class="method {{.Method}}">{{.Method}}</span> <span class="path">{{.Path}}</span></a>{{with .Summary}} <span class="summary">{{.}}</span>{{end}}</li>
+{{end}}</ul>
+</section>
+{{end}}
+<footer>Automatically generated via <a href="https://github.com/simonrichardson/betwixt">Betwixt</a>{{with .Generated}} on {{.}}{{end}}</footer>
+<script>
+document.getElementById("search").addEventListener("input", function (e) {
+  var query = e.target.value.toLowerCase();
+  document.querySelectorAll("section").forEach(function (section) {
+    var visible = 0;
+    section.querySelectorAll("li").forEach(function (li) {
+      var match = li.textContent.toLowerCase().indexOf(query) >= 0;
+      li.style.display = match ? "" : "none";
+      if (match) { visible++; }
+    });
+    section.style.display = visible > 0 ? "" : "none";
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+var htmlPageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Method}} {{.Path}} - {{.Title}}</title>
+<style>` + htmlStyle + `</style>
+</head>
+<body>
+<p><a href="index.html">&larr; {{.Title}}</a></p>
+<h1><span class="method {{.Method}}">{{.Method}}</span> <span class="path">{{.Path}}</span></h1>
+{{with .Tags}}<p>{{range .}}<span class="tag">{{.}}</span> {{end}}</p>{{end}}
+{{with .Summary}}<p class="summary">{{.}}</p>{{end}}
+{{with .Description}}<p class="description">{{.}}</p>{{end}}
+{{range .Responses}}
+<section>
+<h2>Response <span class="status">{{.Status}}</span> {{.StatusText}}</h2>
+{{with .Params}}<details open><summary>Parameters</summary>
+<table>{{range .}}<tr><td>{{.Key}}</td><td>{{.Value}}</td><td>{{if .Optional}}<span class="optional">optional</span>{{else}}required{{end}}</td><td>{{.Presence}}</td></tr>{{end}}</table>
+</details>{{end}}
+{{with .ReqHeaders}}<details><summary>Request Headers</summary>
+<table>{{range .}}<tr><td>{{.Key}}</td><td>{{.Value}}</td><td>{{if .Optional}}<span class="optional">optional</span>{{end}}</td><td>{{.Presence}}</td></tr>{{end}}</table>
+</details>{{end}}
+{{with .ReqBody}}<details open><summary>Request Body</summary><pre>{{.}}</pre></details>{{end}}
+{{with .ReqSchema}}<details><summary>Request Schema</summary><pre>{{.}}</pre></details>{{end}}
+{{range .Snippets}}<details><summary>{{.Name}}</summary><pre>{{.Code}}</pre></details>
+{{end}}{{with .RespHeaders}}<details><summary>Response Headers</summary>
+<table>{{range .}}<tr><td>{{.Key}}</td><td>{{.Value}}</td><td>{{if .Optional}}<span class="optional">optional</span>{{end}}</td><td>{{.Presence}}</td></tr>{{end}}</table>
+</details>{{end}}
+{{with .RespBody}}<details open><summary>Response Body</summary><pre>{{.}}</pre></details>{{end}}
+{{with .RespSchema}}<details><summary>Response Schema</summary><pre>{{.}}</pre></details>{{end}}
+{{with .Stats}}<details><summary>Stats</summary>
+<table>{{range .}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>{{end}}</table>
+</details>{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,239 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// JSON renders the documents as JSON, including all the scored data from the
+// Union and Difference of each part of the document, so the analysis can be
+// post-processed by other tooling.
+type JSON struct {
+	w io.WriteCloser
+}
+
+// NewJSON creates a JSON with the correct dependencies
+func NewJSON(w io.WriteCloser) *JSON {
+	return &JSON{w}
+}
+
+// Output takes a slice of documents and generates a JSON document from them
+func (o JSON) Output(docs []entry.Document) error {
+	res := make([]jsonDocument, 0, len(docs))
+	for _, v := range docs {
+		res = append(res, newJSONDocument(v))
+	}
+
+	bytes, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.w, "%s\n", bytes)
+
+	return o.w.Close()
+}
+
+type jsonDocument struct {
+	Method      jsonString      `json:"method"`
+	Status      jsonStatus      `json:"status"`
+	URL         jsonURL         `json:"url"`
+	Params      jsonMap         `json:"params"`
+	ReqHeaders  jsonMap         `json:"request_headers"`
+	ReqBody     jsonString      `json:"request_body"`
+	ReqSchema   jsonSchema      `json:"request_schema"`
+	RespHeaders jsonMap         `json:"response_headers"`
+	RespBody    jsonString      `json:"response_body"`
+	RespSchema  jsonSchema      `json:"response_schema"`
+	Stats       jsonStats       `json:"stats"`
+	Annotations jsonAnnotations `json:"annotations"`
+}
+
+type jsonAnnotations struct {
+	Summary     string   `json:"summary,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags"`
+}
+
+type jsonStats struct {
+	Count           int         `json:"count"`
+	Latency         jsonLatency `json:"latency_ms"`
+	AvgRequestSize  float64     `json:"avg_request_size"`
+	AvgResponseSize float64     `json:"avg_response_size"`
+}
+
+type jsonLatency struct {
+	Min float64 `json:"min"`
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	Max float64 `json:"max"`
+}
+
+type jsonStringScore struct {
+	String string      `json:"string"`
+	Score  entry.Score `json:"score"`
+}
+
+type jsonString struct {
+	Len        int               `json:"len"`
+	Union      jsonStringScore   `json:"union"`
+	Difference []jsonStringScore `json:"difference"`
+}
+
+type jsonStatusScore struct {
+	Status int         `json:"status"`
+	Score  entry.Score `json:"score"`
+}
+
+type jsonStatus struct {
+	Len        int               `json:"len"`
+	Union      jsonStatusScore   `json:"union"`
+	Difference []jsonStatusScore `json:"difference"`
+}
+
+type jsonHostPathScore struct {
+	Host  string      `json:"host"`
+	Path  string      `json:"path"`
+	Score entry.Score `json:"score"`
+}
+
+type jsonURL struct {
+	Len        int                 `json:"len"`
+	Union      jsonHostPathScore   `json:"union"`
+	Difference []jsonHostPathScore `json:"difference"`
+}
+
+type jsonValuesScore struct {
+	Values map[string][]string `json:"values"`
+	Score  entry.Score         `json:"score"`
+}
+
+type jsonMap struct {
+	Len        int                    `json:"len"`
+	Union      jsonValuesScore        `json:"union"`
+	Difference []jsonValuesScore      `json:"difference"`
+	Presence   map[string]entry.Score `json:"presence"`
+}
+
+type jsonSchema struct {
+	Len    int               `json:"len"`
+	Schema *entry.JSONSchema `json:"schema,omitempty"`
+}
+
+func newJSONDocument(doc entry.Document) jsonDocument {
+	return jsonDocument{
+		Method:      newJSONString(doc.Method),
+		Status:      newJSONStatus(doc.Status),
+		URL:         newJSONURL(doc.URL),
+		Params:      newJSONMap(doc.Params),
+		ReqHeaders:  newJSONMap(doc.ReqHeaders),
+		ReqBody:     newJSONString(doc.ReqBody),
+		ReqSchema:   newJSONSchema(doc.ReqSchema),
+		RespHeaders: newJSONMap(doc.RespHeaders),
+		RespBody:    newJSONString(doc.RespBody),
+		RespSchema:  newJSONSchema(doc.RespSchema),
+		Stats:       newJSONStats(doc.Stats),
+		Annotations: jsonAnnotations{
+			Summary:     doc.Annotations.Summary,
+			Description: doc.Annotations.Description,
+			Tags:        append(make([]string, 0), doc.Annotations.Tags...),
+		},
+	}
+}
+
+func newJSONStats(s *entry.Stats) jsonStats {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return jsonStats{
+		Count: s.Len(),
+		Latency: jsonLatency{
+			Min: ms(s.Min()),
+			P50: ms(s.P50()),
+			P95: ms(s.P95()),
+			Max: ms(s.Max()),
+		},
+		AvgRequestSize:  s.AvgReqSize(),
+		AvgResponseSize: s.AvgRespSize(),
+	}
+}
+
+func newJSONString(s *entry.String) jsonString {
+	union := s.Union()
+	res := jsonString{
+		Len:        s.Len(),
+		Union:      jsonStringScore{union.String, union.Score},
+		Difference: make([]jsonStringScore, 0),
+	}
+	for _, v := range s.Difference() {
+		res.Difference = append(res.Difference, jsonStringScore{v.String, v.Score})
+	}
+	return res
+}
+
+func newJSONStatus(s *entry.Status) jsonStatus {
+	union := s.Union()
+	res := jsonStatus{
+		Len:        s.Len(),
+		Union:      jsonStatusScore{union.Status, union.Score},
+		Difference: make([]jsonStatusScore, 0),
+	}
+	for _, v := range s.Difference() {
+		res.Difference = append(res.Difference, jsonStatusScore{v.Status, v.Score})
+	}
+	return res
+}
+
+func newJSONURL(u *entry.URL) jsonURL {
+	union := u.Union()
+	res := jsonURL{
+		Len:        u.Len(),
+		Union:      jsonHostPathScore{union.HostPath.Host, union.HostPath.Path, union.Score},
+		Difference: make([]jsonHostPathScore, 0),
+	}
+	for _, v := range u.Difference() {
+		res.Difference = append(res.Difference, jsonHostPathScore{v.HostPath.Host, v.HostPath.Path, v.Score})
+	}
+	return res
+}
+
+func newJSONMap(m *entry.Map) jsonMap {
+	res := jsonMap{
+		Len:        m.Len(),
+		Union:      newJSONValuesScore(m.Union()),
+		Difference: make([]jsonValuesScore, 0),
+		Presence:   make(map[string]entry.Score, 0),
+	}
+	for k := range res.Union.Values {
+		res.Presence[k] = m.Presence(k)
+	}
+	for _, v := range m.Difference() {
+		score := newJSONValuesScore(v)
+		for k := range score.Values {
+			res.Presence[k] = m.Presence(k)
+		}
+		res.Difference = append(res.Difference, score)
+	}
+	return res
+}
+
+func newJSONValuesScore(v entry.ValuesScore) jsonValuesScore {
+	res := jsonValuesScore{
+		Values: make(map[string][]string, len(v.Values)),
+		Score:  v.Score,
+	}
+	v.Values.Walk(func(k string, v interface{}) {
+		res.Values[k] = append(make([]string, 0), entry.ToStrings(v)...)
+	})
+	return res
+}
+
+func newJSONSchema(s *entry.Schema) jsonSchema {
+	return jsonSchema{
+		Len:    s.Len(),
+		Schema: s.JSONSchema(),
+	}
+}
@@ -8,17 +8,25 @@ import (
 	"time"
 
 	"github.com/SimonRichardson/betwixt/pkg/entry"
+	"github.com/SimonRichardson/betwixt/pkg/output/snippet"
 )
 
-const autoGeneratedTemplate = `
+const autoGeneratedNotice = `
 The following was automatically generated via [Betwixt](https://github.com/simonrichardson/betwixt).
-Date generated on: %s
+`
+
+const autoGeneratedTemplate = autoGeneratedNotice + `Date generated on: %s
 `
 
 // Options define certain setup values for rendering markdown
 type Options struct {
-	Header    string
-	Optionals bool
+	Header        string
+	Optionals     bool
+	Schemas       bool
+	Snippets      []snippet.Language
+	OmitTimestamp bool
+	Presence      bool
+	Stats         bool
 }
 
 // NewApiaryOptions make new Options for the Apiary format
@@ -26,6 +34,7 @@ func NewApiaryOptions(name string) Options {
 	return Options{
 		Header:    fmt.Sprintf("FORMAT: 1A\n# %s Blueprint\n", name),
 		Optionals: true,
+		Schemas:   true,
 	}
 }
 
@@ -47,67 +56,131 @@ func (o Markdown) Output(docs []entry.Document) error {
 		fmt.Fprintf(o.w, "%s\n", o.options.Header)
 	}
 
-	fmt.Fprintf(o.w, autoGeneratedTemplate, time.Now().Format(time.RFC3339))
+	writeGenerated(o.w, o.options.OmitTimestamp)
 
-	for _, v := range docs {
-		fmt.Fprintf(o.w, "# %s %s\n\n", v.Method.String(), v.URL.String())
-		fmt.Fprintf(o.w, "+ Request\n")
-
-		if v.Params.Len() > 0 {
-			fmt.Fprintf(o.w, "    + Parameters\n\n")
-			writeParams(o.w, v.Params, o.options)
+	// Documents that only differ by status are rendered as a single action
+	// with multiple responses, grouped by their tags.
+	for _, group := range groupTags(groupOperations(docs)) {
+		if len(group.Tag) > 0 {
+			fmt.Fprintf(o.w, "# Group %s\n\n", group.Tag)
 		}
 
-		if v.ReqHeaders.Len() > 0 {
-			fmt.Fprintf(o.w, "    + Headers\n\n")
-			writeHeaders(o.w, v.ReqHeaders, o.options)
-		}
+		for _, op := range group.Operations {
+			fmt.Fprintf(o.w, "# %s %s\n\n", op.Method, op.Docs[0].URL.String())
+			writeAnnotations(o.w, op.annotations())
 
-		if union := v.ReqBody.String(); len(union) > 0 {
-			fmt.Fprintf(o.w, "    + Body\n\n")
-			switch getContentType(v.ReqHeaders) {
-			case "application/json", "text/json":
-				if err := writeBody(o.w, union); err != nil {
+			for _, v := range op.Docs {
+				if err := o.writeDocument(v); err != nil {
 					return err
 				}
-			default:
-				fmt.Fprintf(o.w, "            %s\n\n", union)
 			}
 		}
+	}
+
+	o.w.Close()
 
-		fmt.Fprintf(o.w, "+ Response %d\n", v.Status.Union().Status)
+	return nil
+}
+
+func (o Markdown) writeDocument(v entry.Document) error {
+	fmt.Fprintf(o.w, "+ Request\n")
+
+	if v.Params.Len() > 0 {
+		fmt.Fprintf(o.w, "    + Parameters\n\n")
+		writeParams(o.w, v.Params, o.options)
+	}
+
+	if v.ReqHeaders.Len() > 0 {
+		fmt.Fprintf(o.w, "    + Headers\n\n")
+		writeHeaders(o.w, v.ReqHeaders, o.options)
+	}
 
-		if v.RespHeaders.Len() > 0 {
-			fmt.Fprintf(o.w, "    + Headers\n\n")
-			writeHeaders(o.w, v.RespHeaders, o.options)
+	if union := v.ReqBody.String(); len(union) > 0 {
+		fmt.Fprintf(o.w, "    + Body\n\n")
+		switch getContentType(v.ReqHeaders) {
+		case "application/json", "text/json":
+			// Truncated bodies aren't valid JSON, so they're written as is.
+			if err := writeBody(o.w, union); err != nil {
+				fmt.Fprintf(o.w, "            %s\n\n", union)
+			}
+		default:
+			fmt.Fprintf(o.w, "            %s\n\n", union)
 		}
+	}
 
-		if union := v.RespBody.String(); len(union) > 0 {
-			fmt.Fprintf(o.w, "    + Body\n\n")
-			switch getContentType(v.RespHeaders) {
-			case "application/json", "text/json":
-				if err := writeBody(o.w, union); err != nil {
-					return err
-				}
-			default:
+	if o.options.Schemas {
+		if err := writeSchema(o.w, v.ReqSchema); err != nil {
+			return err
+		}
+	}
+
+	if err := writeSnippets(o.w, "    + Snippet (%s)\n\n", "            ", v, o.options.Snippets); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.w, "+ Response %d\n", v.Status.Union().Status)
+
+	if v.RespHeaders.Len() > 0 {
+		fmt.Fprintf(o.w, "    + Headers\n\n")
+		writeHeaders(o.w, v.RespHeaders, o.options)
+	}
+
+	if union := v.RespBody.String(); len(union) > 0 {
+		fmt.Fprintf(o.w, "    + Body\n\n")
+		switch getContentType(v.RespHeaders) {
+		case "application/json", "text/json":
+			// Truncated bodies aren't valid JSON, so they're written as is.
+			if err := writeBody(o.w, union); err != nil {
 				fmt.Fprintf(o.w, "            %s\n\n", union)
 			}
+		default:
+			fmt.Fprintf(o.w, "            %s\n\n", union)
 		}
 	}
 
-	o.w.Close()
+	if o.options.Schemas {
+		if err := writeSchema(o.w, v.RespSchema); err != nil {
+			return err
+		}
+	}
+
+	if o.options.Stats {
+		writeStats(o.w, "    + Stats\n\n", "            ", v.Stats)
+	}
 
 	return nil
 }
 
+// writeAnnotations writes the summary and description as paragraphs
+func writeAnnotations(w io.Writer, a entry.Annotations) {
+	if len(a.Summary) > 0 {
+		fmt.Fprintf(w, "%s\n\n", a.Summary)
+	}
+	if len(a.Description) > 0 {
+		fmt.Fprintf(w, "%s\n\n", a.Description)
+	}
+}
+
+// writeGenerated writes the auto generated notice, the date can be omitted so
+// that the output is reproducible.
+func writeGenerated(w io.Writer, omitTimestamp bool) {
+	if omitTimestamp {
+		fmt.Fprint(w, autoGeneratedNotice)
+		return
+	}
+	fmt.Fprintf(w, autoGeneratedTemplate, time.Now().Format(time.RFC3339))
+}
+
 func writeParams(w io.Writer, params *entry.Map, options Options) {
 	params.Union().Values.Walk(func(k string, v interface{}) {
-		fmt.Fprintf(w, "            %s ('%s')\n", k, entry.ToStrings(v).Join())
+		attributes := presence(params, k, options.Presence)
+		fmt.Fprintf(w, "            %s (%s'%s')\n", k, attributes, entry.ToStrings(v).Join())
 	})
 	if options.Optionals {
 		for _, v := range params.Difference() {
 			v.Values.Walk(func(k string, v interface{}) {
-				fmt.Fprintf(w, "            %s (optional, '%s')\n", k, entry.ToStrings(v).Join())
+				attributes := "optional, " + presence(params, k, options.Presence)
+				fmt.Fprintf(w, "            %s (%s'%s')\n", k, attributes, entry.ToStrings(v).Join())
 			})
 		}
 	}
@@ -116,18 +189,40 @@ func writeParams(w io.Writer, params *entry.Map, options Options) {
 
 func writeHeaders(w io.Writer, params *entry.Map, options Options) {
 	params.Union().Values.Walk(func(k string, v interface{}) {
-		fmt.Fprintf(w, "            %s: %s\n", k, entry.ToStrings(v).Join())
+		fmt.Fprintf(w, "            %s: %s", k, entry.ToStrings(v).Join())
+		if options.Presence {
+			fmt.Fprintf(w, " (%s)", percentage(params.Presence(k)))
+		}
+		fmt.Fprintln(w, "")
 	})
 	if options.Optionals {
 		for _, v := range params.Difference() {
 			v.Values.Walk(func(k string, v interface{}) {
-				fmt.Fprintf(w, "            %s: %s (optional)\n", k, entry.ToStrings(v).Join())
+				fmt.Fprintf(w, "            %s: %s (optional", k, entry.ToStrings(v).Join())
+				if options.Presence {
+					fmt.Fprintf(w, ", %s", percentage(params.Presence(k)))
+				}
+				fmt.Fprintln(w, ")")
 			})
 		}
 	}
 	fmt.Fprintln(w, "")
 }
 
+// presence returns the presence of the key as a percentage followed by a
+// separator, if it's enabled.
+func presence(m *entry.Map, key string, enabled bool) string {
+	if !enabled {
+		return ""
+	}
+	return percentage(m.Presence(key)) + ", "
+}
+
+// percentage formats a score between 0 and 1 as a percentage
+func percentage(score entry.Score) string {
+	return fmt.Sprintf("%.0f%%", float64(score)*100)
+}
+
 func getContentType(params *entry.Map) (res string) {
 	params.Union().Values.Walk(func(k string, v interface{}) {
 		if strings.ToLower(k) == "content-type" {
@@ -149,3 +244,17 @@ func writeBody(w io.Writer, body string) error {
 	fmt.Fprintf(w, "            %s\n\n", bytes)
 	return nil
 }
+
+func writeSchema(w io.Writer, schema *entry.Schema) error {
+	doc := schema.JSONSchema()
+	if doc == nil {
+		return nil
+	}
+	bytes, err := json.MarshalIndent(doc, "            ", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "    + Schema\n\n")
+	fmt.Fprintf(w, "            %s\n\n", bytes)
+	return nil
+}
@@ -0,0 +1,389 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification that is rendered
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIOptions define certain setup values for rendering an OpenAPI document
+type OpenAPIOptions struct {
+	Title       string
+	Description string
+	Version     string
+	Servers     []string
+}
+
+// NewOpenAPIOptions makes new OpenAPIOptions with a title and sane defaults
+func NewOpenAPIOptions(title string) OpenAPIOptions {
+	return OpenAPIOptions{
+		Title:   title,
+		Version: "1.0.0",
+	}
+}
+
+// OpenAPI renders an OpenAPI 3.x document. The document is written as JSON,
+// which is also valid YAML, so it can be consumed by either kind of tooling.
+type OpenAPI struct {
+	w       io.WriteCloser
+	options OpenAPIOptions
+}
+
+// NewOpenAPI creates an OpenAPI with the correct dependencies
+func NewOpenAPI(w io.WriteCloser, options OpenAPIOptions) *OpenAPI {
+	return &OpenAPI{w, options}
+}
+
+// Output takes a slice of documents and generates an OpenAPI document from them
+func (o OpenAPI) Output(docs []entry.Document) error {
+	spec := openAPISpec{
+		OpenAPI: OpenAPIVersion,
+		Info: openAPIInfo{
+			Title:       o.options.Title,
+			Description: o.options.Description,
+			Version:     o.options.Version,
+		},
+		Paths: make(map[string]*openAPIPathItem, 0),
+	}
+	for _, v := range o.options.Servers {
+		spec.Servers = append(spec.Servers, openAPIServer{URL: v})
+	}
+
+	ops := groupOperations(docs)
+	spec.Tags = openAPITags(ops)
+
+	operationIDs := make(map[string]int, 0)
+	for _, op := range ops {
+		path := templatePath(op.Path)
+		item, ok := spec.Paths[path]
+		if !ok {
+			item = &openAPIPathItem{}
+			spec.Paths[path] = item
+		}
+
+		operation, err := newOpenAPIOperation(op)
+		if err != nil {
+			return err
+		}
+		operation.OperationID = uniqueOperationID(operationIDs, op.Method, path)
+
+		if !item.set(op.Method, operation) {
+			return fmt.Errorf("unsupported method %q for %s", op.Method, path)
+		}
+	}
+
+	bytes, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.w, "%s\n", bytes)
+
+	return o.w.Close()
+}
+
+type openAPISpec struct {
+	OpenAPI string                      `json:"openapi"`
+	Info    openAPIInfo                 `json:"info"`
+	Servers []openAPIServer             `json:"servers,omitempty"`
+	Tags    []openAPITag                `json:"tags,omitempty"`
+	Paths   map[string]*openAPIPathItem `json:"paths"`
+}
+
+type openAPITag struct {
+	Name string `json:"name"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIPathItem struct {
+	Get     *openAPIOperation `json:"get,omitempty"`
+	Put     *openAPIOperation `json:"put,omitempty"`
+	Post    *openAPIOperation `json:"post,omitempty"`
+	Delete  *openAPIOperation `json:"delete,omitempty"`
+	Options *openAPIOperation `json:"options,omitempty"`
+	Head    *openAPIOperation `json:"head,omitempty"`
+	Patch   *openAPIOperation `json:"patch,omitempty"`
+	Trace   *openAPIOperation `json:"trace,omitempty"`
+}
+
+func (p *openAPIPathItem) set(method string, op *openAPIOperation) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		p.Get = op
+	case http.MethodPut:
+		p.Put = op
+	case http.MethodPost:
+		p.Post = op
+	case http.MethodDelete:
+		p.Delete = op
+	case http.MethodOptions:
+		p.Options = op
+	case http.MethodHead:
+		p.Head = op
+	case http.MethodPatch:
+		p.Patch = op
+	case http.MethodTrace:
+		p.Trace = op
+	default:
+		return false
+	}
+	return true
+}
+
+type openAPIOperation struct {
+	Tags        []string                    `json:"tags,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	OperationID string                      `json:"operationId,omitempty"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *openAPISchema `json:"schema"`
+	Example  interface{}    `json:"example,omitempty"`
+}
+
+type openAPIRequestBody struct {
+	Content map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Headers     map[string]openAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIHeader struct {
+	Required bool           `json:"required,omitempty"`
+	Schema   *openAPISchema `json:"schema"`
+	Example  interface{}    `json:"example,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema  *entry.JSONSchema `json:"schema,omitempty"`
+	Example interface{}       `json:"example,omitempty"`
+}
+
+type openAPISchema struct {
+	Type string `json:"type"`
+}
+
+func newOpenAPIOperation(op operation) (*openAPIOperation, error) {
+	annotations := op.annotations()
+	res := &openAPIOperation{
+		Tags:        annotations.Tags,
+		Summary:     annotations.Summary,
+		Description: annotations.Description,
+		Responses:   make(map[string]*openAPIResponse, 0),
+	}
+
+	var (
+		params []openAPIParameter
+		seen   = make(map[string]int, 0)
+	)
+	for _, doc := range op.Docs {
+		for _, v := range collectParameters(doc) {
+			seen[v.In+v.Name]++
+			params = mergeParameter(params, v)
+		}
+
+		if res.RequestBody == nil {
+			if union := doc.ReqBody.String(); len(union) > 0 {
+				contentType := contentTypeOrDefault(getContentType(doc.ReqHeaders))
+				res.RequestBody = &openAPIRequestBody{
+					Content: map[string]openAPIMediaType{
+						contentType: {
+							Schema:  doc.ReqSchema.JSONSchema(),
+							Example: exampleBody(contentType, union),
+						},
+					},
+				}
+			}
+		}
+
+		status := doc.Status.Union().Status
+		response := &openAPIResponse{
+			Description: statusDescription(status),
+		}
+		doc.RespHeaders.Union().Values.Walk(func(k string, v interface{}) {
+			if isReservedHeader(k) {
+				return
+			}
+			if response.Headers == nil {
+				response.Headers = make(map[string]openAPIHeader, 0)
+			}
+			response.Headers[k] = openAPIHeader{
+				Required: true,
+				Schema:   &openAPISchema{Type: "string"},
+				Example:  entry.ToStrings(v).Join(),
+			}
+		})
+		if union := doc.RespBody.String(); len(union) > 0 {
+			contentType := contentTypeOrDefault(getContentType(doc.RespHeaders))
+			response.Content = map[string]openAPIMediaType{
+				contentType: {
+					Schema:  doc.RespSchema.JSONSchema(),
+					Example: exampleBody(contentType, union),
+				},
+			}
+		}
+		res.Responses[strconv.Itoa(status)] = response
+	}
+
+	// Parameters missing from some of the captured documents can't be
+	// required for the operation.
+	for k, v := range params {
+		switch {
+		case v.In == "path":
+			params[k].Required = true
+		case seen[v.In+v.Name] < len(op.Docs):
+			params[k].Required = false
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].In != params[j].In {
+			return params[i].In < params[j].In
+		}
+		return params[i].Name < params[j].Name
+	})
+	res.Parameters = params
+
+	return res, nil
+}
+
+// openAPITags returns the tags of all the operations
+func openAPITags(ops []operation) []openAPITag {
+	var res []openAPITag
+	for _, v := range allTags(ops) {
+		res = append(res, openAPITag{Name: v})
+	}
+	return res
+}
+
+func collectParameters(doc entry.Document) []openAPIParameter {
+	var (
+		res  []openAPIParameter
+		seen = make(map[string]bool, 0)
+	)
+
+	add := func(in string, required bool) func(string, interface{}) {
+		return func(k string, v interface{}) {
+			where, name := in, k
+			if strings.Index(name, ":") == 0 {
+				where, name = "path", name[1:]
+			}
+			if seen[where+name] {
+				return
+			}
+			seen[where+name] = true
+
+			res = append(res, openAPIParameter{
+				Name:     name,
+				In:       where,
+				Required: required,
+				Schema:   &openAPISchema{Type: "string"},
+				Example:  entry.ToStrings(v).Join(),
+			})
+		}
+	}
+
+	doc.Params.Union().Values.Walk(add("query", true))
+	for _, v := range doc.Params.Difference() {
+		v.Values.Walk(add("query", false))
+	}
+
+	headers := func(required bool) func(string, interface{}) {
+		fn := add("header", required)
+		return func(k string, v interface{}) {
+			if !isReservedHeader(k) {
+				fn(k, v)
+			}
+		}
+	}
+	doc.ReqHeaders.Union().Values.Walk(headers(true))
+	for _, v := range doc.ReqHeaders.Difference() {
+		v.Values.Walk(headers(false))
+	}
+
+	return res
+}
+
+func mergeParameter(params []openAPIParameter, param openAPIParameter) []openAPIParameter {
+	for k, v := range params {
+		if v.Name == param.Name && v.In == param.In {
+			params[k].Required = v.Required && param.Required
+			return params
+		}
+	}
+	return append(params, param)
+}
+
+// isReservedHeader returns true for headers that OpenAPI describes with
+// dedicated fields rather than parameters.
+func isReservedHeader(name string) bool {
+	switch strings.ToLower(name) {
+	case "accept", "content-type", "authorization":
+		return true
+	}
+	return false
+}
+
+func exampleBody(contentType, body string) interface{} {
+	if isJSON(contentType) {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(body), &doc); err == nil {
+			return doc
+		}
+	}
+	return body
+}
+
+func statusDescription(status int) string {
+	if text := http.StatusText(status); len(text) > 0 {
+		return text
+	}
+	return fmt.Sprintf("Status %d", status)
+}
+
+func uniqueOperationID(ids map[string]int, method, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			id += "By"
+			segment = segment[1 : len(segment)-1]
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		}) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+
+	ids[id]++
+	if n := ids[id]; n > 1 {
+		return fmt.Sprintf("%s%d", id, n)
+	}
+	return id
+}
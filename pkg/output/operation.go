@@ -0,0 +1,150 @@
+package output
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// operation groups all the documents that share both a method and a path,
+// which differ only by their response status.
+type operation struct {
+	Method string
+	Path   string
+	Docs   []entry.Document
+}
+
+func groupOperations(docs []entry.Document) []operation {
+	var (
+		res     []operation
+		indexes = make(map[string]int, 0)
+	)
+	for _, v := range docs {
+		var (
+			method = v.Method.String()
+			path   = v.Path()
+			key    = method + " " + path
+		)
+		index, ok := indexes[key]
+		if !ok {
+			index = len(res)
+			indexes[key] = index
+			res = append(res, operation{
+				Method: method,
+				Path:   path,
+			})
+		}
+		res[index].Docs = append(res[index].Docs, v)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Path != res[j].Path {
+			return res[i].Path < res[j].Path
+		}
+		return res[i].Method < res[j].Method
+	})
+	for _, v := range res {
+		sort.SliceStable(v.Docs, func(i, j int) bool {
+			return v.Docs[i].Status.Union().Status < v.Docs[j].Status.Union().Status
+		})
+	}
+
+	return res
+}
+
+// annotations returns the annotations of all the documents of the operation
+func (o operation) annotations() entry.Annotations {
+	res := make([]entry.Annotations, len(o.Docs))
+	for k, v := range o.Docs {
+		res[k] = v.Annotations
+	}
+	return entry.MergeAnnotations(res...)
+}
+
+// tag returns the first tag of the operation, which is the section it's
+// rendered in.
+func (o operation) tag() string {
+	if tags := o.annotations().Tags; len(tags) > 0 {
+		return tags[0]
+	}
+	return ""
+}
+
+// tagGroup is a collection of operations that share the same first tag.
+type tagGroup struct {
+	Tag        string
+	Operations []operation
+}
+
+// groupTags groups the operations by their first tag, sorted by the tag.
+// Operations without any tags are grouped first, with an empty tag.
+func groupTags(ops []operation) []tagGroup {
+	var (
+		res     []tagGroup
+		indexes = make(map[string]int, 0)
+	)
+	for _, op := range ops {
+		tag := op.tag()
+		index, ok := indexes[tag]
+		if !ok {
+			index = len(res)
+			indexes[tag] = index
+			res = append(res, tagGroup{Tag: tag})
+		}
+		res[index].Operations = append(res[index].Operations, op)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Tag < res[j].Tag
+	})
+	return res
+}
+
+// allTags returns every tag of the operations, sorted.
+func allTags(ops []operation) []string {
+	var (
+		res  []string
+		seen = make(map[string]bool, 0)
+	)
+	for _, op := range ops {
+		for _, v := range op.annotations().Tags {
+			if !seen[v] {
+				seen[v] = true
+				res = append(res, v)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// templatePath converts a normalised path using ":name" segments into a path
+// template using "{name}" segments.
+func templatePath(path string) string {
+	segments := strings.Split(path, "/")
+	for k, v := range segments {
+		if strings.Index(v, ":") == 0 && len(v) > 1 {
+			segments[k] = "{" + v[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func isJSON(contentType string) bool {
+	switch contentType {
+	case "application/json", "text/json":
+		return true
+	}
+	return strings.HasSuffix(contentType, "+json")
+}
+
+func contentTypeOrDefault(contentType string) string {
+	if index := strings.Index(contentType, ";"); index >= 0 {
+		contentType = contentType[:index]
+	}
+	if contentType = strings.TrimSpace(contentType); len(contentType) < 1 {
+		return "application/octet-stream"
+	}
+	return contentType
+}
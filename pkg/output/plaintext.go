@@ -6,57 +6,114 @@ import (
 	"text/tabwriter"
 
 	"github.com/SimonRichardson/betwixt/pkg/entry"
+	"github.com/SimonRichardson/betwixt/pkg/output/snippet"
 )
 
+// PlaintextOptions define certain setup values for rendering plaintext
+type PlaintextOptions struct {
+	Snippets []snippet.Language
+	Presence bool
+	Stats    bool
+}
+
 type Plaintext struct {
-	w io.WriteCloser
+	w       io.WriteCloser
+	options PlaintextOptions
 }
 
 func NewPlaintext(w io.WriteCloser) *Plaintext {
-	return &Plaintext{w}
+	return &Plaintext{w, PlaintextOptions{}}
+}
+
+// NewPlaintextWithOptions creates a Plaintext with some PlaintextOptions for
+// rendering
+func NewPlaintextWithOptions(w io.WriteCloser, options PlaintextOptions) *Plaintext {
+	return &Plaintext{w, options}
 }
 
 func (o Plaintext) Output(docs []entry.Document) error {
-	for _, v := range docs {
-		fmt.Fprintf(o.w, "%s %s - %s\n", v.Method.String(), v.Status.String(), v.URL.String())
-		fmt.Fprintf(o.w, "- Parameters:\n")
+	// Documents are written in sections by their tags, untagged documents
+	// come first.
+	for _, group := range groupTags(groupOperations(docs)) {
+		if len(group.Tag) > 0 {
+			fmt.Fprintf(o.w, "== %s ==\n\n", group.Tag)
+		}
 
-		// Common
-		writeMap(o.w, v.Params)
+		for _, op := range group.Operations {
+			for _, v := range op.Docs {
+				if err := o.writeDocument(v); err != nil {
+					return err
+				}
+			}
+		}
+	}
 
-		fmt.Fprintln(o.w, "- Request Headers:")
+	o.w.Close()
 
-		writeMap(o.w, v.ReqHeaders)
+	return nil
+}
 
-		if union := v.ReqBody.String(); len(union) > 0 {
-			fmt.Fprintln(o.w, "- Request Body:")
-			fmt.Fprintf(o.w, "\n  %s\n\n", union)
-		}
+func (o Plaintext) writeDocument(v entry.Document) error {
+	fmt.Fprintf(o.w, "%s %s - %s\n", v.Method.String(), v.Status.String(), v.URL.String())
+	if summary := v.Annotations.Summary; len(summary) > 0 {
+		fmt.Fprintf(o.w, "- Summary: %s\n", summary)
+	}
+	if description := v.Annotations.Description; len(description) > 0 {
+		fmt.Fprintf(o.w, "- Description: %s\n", description)
+	}
+	if tags := v.Annotations.Tags; len(tags) > 0 {
+		fmt.Fprintf(o.w, "- Tags: %s\n", entry.Strings(tags).Join())
+	}
+	fmt.Fprintf(o.w, "- Parameters:\n")
 
-		fmt.Fprintln(o.w, "- Response Headers:")
+	// Common
+	writeMap(o.w, v.Params, o.options.Presence)
 
-		writeMap(o.w, v.RespHeaders)
+	fmt.Fprintln(o.w, "- Request Headers:")
 
-		if union := v.RespBody.String(); len(union) > 0 {
-			fmt.Fprintln(o.w, "- Response Body:")
-			fmt.Fprintf(o.w, "\n  %s\n", union)
-		}
+	writeMap(o.w, v.ReqHeaders, o.options.Presence)
+
+	if union := v.ReqBody.String(); len(union) > 0 {
+		fmt.Fprintln(o.w, "- Request Body:")
+		fmt.Fprintf(o.w, "\n  %s\n\n", union)
 	}
 
-	o.w.Close()
+	if err := writeSnippets(o.w, "- Snippet (%s):\n\n", "  ", v, o.options.Snippets); err != nil {
+		return err
+	}
 
+	fmt.Fprintln(o.w, "- Response Headers:")
+
+	writeMap(o.w, v.RespHeaders, o.options.Presence)
+
+	if union := v.RespBody.String(); len(union) > 0 {
+		fmt.Fprintln(o.w, "- Response Body:")
+		fmt.Fprintf(o.w, "\n  %s\n", union)
+	}
+
+	if o.options.Stats {
+		writeStats(o.w, "- Stats:\n\n", "  ", v.Stats)
+	}
 	return nil
 }
 
-func writeMap(w io.Writer, params *entry.Map) {
+func writeMap(w io.Writer, params *entry.Map, presence bool) {
 	writer := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
 
 	params.Union().Values.Walk(func(k string, v interface{}) {
-		fmt.Fprintf(writer, "\t・\t%s\t%v\n", k, entry.ToStrings(v).Join())
+		fmt.Fprintf(writer, "\t・\t%s\t%v", k, entry.ToStrings(v).Join())
+		if presence {
+			fmt.Fprintf(writer, " (%s)", percentage(params.Presence(k)))
+		}
+		fmt.Fprintln(writer, "")
 	})
 	for _, v := range params.Difference() {
 		v.Values.Walk(func(k string, v interface{}) {
-			fmt.Fprintf(writer, "\t・\t%s\t%v (optional)\n", k, entry.ToStrings(v).Join())
+			fmt.Fprintf(writer, "\t・\t%s\t%v (optional", k, entry.ToStrings(v).Join())
+			if presence {
+				fmt.Fprintf(writer, ", %s", percentage(params.Presence(k)))
+			}
+			fmt.Fprintln(writer, ")")
 		})
 	}
 	writer.Flush()
@@ -0,0 +1,298 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// PostmanSchema is the schema of the Postman Collection format that is
+// rendered
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanHostVariable is the name of the collection variable holding the first
+// host, any other hosts are numbered from 2.
+const postmanHostVariable = "baseUrl"
+
+// Postman renders a Postman Collection v2.1, which can also be imported by
+// Insomnia. Each document becomes a request, with the captured responses saved
+// as examples.
+type Postman struct {
+	w    io.WriteCloser
+	name string
+}
+
+// NewPostman creates a Postman with the correct dependencies
+func NewPostman(w io.WriteCloser, name string) *Postman {
+	return &Postman{w, name}
+}
+
+// Output takes a slice of documents and generates a Postman collection from
+// them
+func (o Postman) Output(docs []entry.Document) error {
+	collection := postmanCollection{
+		Info: postmanInfo{
+			Name:   o.name,
+			Schema: PostmanSchema,
+		},
+		Item: make([]postmanItem, 0),
+	}
+
+	// Each host is extracted into a collection variable, so the collection
+	// can be pointed at another environment.
+	// Tagged operations are placed in a folder for their first tag.
+	hosts := newPostmanHosts()
+	for _, group := range groupTags(groupOperations(docs)) {
+		items := make([]postmanItem, 0, len(group.Operations))
+		for _, op := range group.Operations {
+			items = append(items, newPostmanItem(op, hosts))
+		}
+
+		if len(group.Tag) < 1 {
+			collection.Item = append(collection.Item, items...)
+			continue
+		}
+		collection.Item = append(collection.Item, postmanItem{
+			Name: group.Tag,
+			Item: items,
+		})
+	}
+	collection.Variable = hosts.variables
+
+	bytes, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.w, "%s\n", bytes)
+
+	return o.w.Close()
+}
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// postmanItem is either a request, or a folder of items
+type postmanItem struct {
+	Name     string            `json:"name"`
+	Request  *postmanRequest   `json:"request,omitempty"`
+	Response []postmanResponse `json:"response,omitempty"`
+	Item     []postmanItem     `json:"item,omitempty"`
+}
+
+type postmanRequest struct {
+	Method      string          `json:"method"`
+	Header      []postmanHeader `json:"header"`
+	Body        *postmanBody    `json:"body,omitempty"`
+	URL         postmanURL      `json:"url"`
+	Description string          `json:"description,omitempty"`
+}
+
+type postmanHeader struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+type postmanBody struct {
+	Mode    string                 `json:"mode"`
+	Raw     string                 `json:"raw"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []postmanHeader   `json:"query,omitempty"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+type postmanResponse struct {
+	Name                   string          `json:"name"`
+	OriginalRequest        postmanRequest  `json:"originalRequest"`
+	Status                 string          `json:"status"`
+	Code                   int             `json:"code"`
+	PostmanPreviewLanguage string          `json:"_postman_previewlanguage,omitempty"`
+	Header                 []postmanHeader `json:"header"`
+	Body                   string          `json:"body"`
+}
+
+// postmanHosts holds a collection variable for every scheme and host
+type postmanHosts struct {
+	names     map[string]string
+	variables []postmanVariable
+}
+
+func newPostmanHosts() *postmanHosts {
+	return &postmanHosts{
+		names: make(map[string]string, 0),
+	}
+}
+
+// variable returns the name of the variable for the scheme and host of the
+// document. Documents without a host use the origin they were received on, or
+// "http://localhost" if there isn't one.
+func (h *postmanHosts) variable(doc entry.Document) string {
+	origin := "http://localhost"
+	if host := doc.URL.Union().HostPath.Host; len(host) > 0 {
+		scheme := doc.Scheme.Union().String
+		if len(scheme) < 1 {
+			scheme = "http"
+		}
+		origin = scheme + "://" + host
+	} else if doc.Origin != nil && len(doc.Origin.Union().String) > 0 {
+		origin = doc.Origin.Union().String
+	}
+	if name, ok := h.names[origin]; ok {
+		return name
+	}
+	name := postmanHostVariable
+	if n := len(h.variables); n > 0 {
+		name = fmt.Sprintf("%s%d", postmanHostVariable, n+1)
+	}
+	h.names[origin] = name
+	h.variables = append(h.variables, postmanVariable{Key: name, Value: origin, Type: "string"})
+	return name
+}
+
+func newPostmanItem(op operation, hosts *postmanHosts) postmanItem {
+	// The request is built from the first document, which has the lowest
+	// status and is the one most likely to be reproduced.
+	var (
+		annotations = op.annotations()
+		request     = newPostmanRequest(op, op.Docs[0], hosts.variable(op.Docs[0]))
+		name        = annotations.Summary
+	)
+	if len(name) < 1 {
+		name = fmt.Sprintf("%s %s", op.Method, templatePath(op.Path))
+	}
+	request.Description = annotations.Description
+
+	item := postmanItem{
+		Name:    name,
+		Request: &request,
+	}
+	for _, v := range op.Docs {
+		status := v.Status.Union().Status
+		response := postmanResponse{
+			Name:            fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			OriginalRequest: newPostmanRequest(op, v, hosts.variable(v)),
+			Status:          http.StatusText(status),
+			Code:            status,
+			Header:          postmanHeaders(v.RespHeaders),
+			Body:            v.RespBody.String(),
+		}
+		if isJSON(contentTypeOrDefault(getContentType(v.RespHeaders))) {
+			response.PostmanPreviewLanguage = "json"
+		}
+		item.Response = append(item.Response, response)
+	}
+	return item
+}
+
+func newPostmanRequest(op operation, doc entry.Document, host string) postmanRequest {
+	var (
+		path  []string
+		vars  []postmanVariable
+		query []postmanHeader
+	)
+	params := make(map[string]string, 0)
+	doc.Params.Union().Values.Walk(func(k string, v interface{}) {
+		params[k] = entry.ToStrings(v).Join()
+		if strings.Index(k, ":") != 0 {
+			query = append(query, postmanHeader{Key: k, Value: params[k]})
+		}
+	})
+	for _, v := range doc.Params.Difference() {
+		v.Values.Walk(func(k string, v interface{}) {
+			if strings.Index(k, ":") != 0 {
+				query = append(query, postmanHeader{
+					Key:      k,
+					Value:    entry.ToStrings(v).Join(),
+					Disabled: true,
+				})
+			}
+		})
+	}
+
+	for _, v := range splitSegments(op.Path) {
+		path = append(path, v)
+		if strings.Index(v, ":") == 0 {
+			vars = append(vars, postmanVariable{Key: v[1:], Value: params[v]})
+		}
+	}
+
+	raw := "{{" + host + "}}/" + strings.Join(path, "/")
+	var enabled []string
+	for _, v := range query {
+		if !v.Disabled {
+			enabled = append(enabled, v.Key+"="+v.Value)
+		}
+	}
+	if len(enabled) > 0 {
+		raw += "?" + strings.Join(enabled, "&")
+	}
+
+	request := postmanRequest{
+		Method: op.Method,
+		Header: postmanRequestHeaders(doc.ReqHeaders),
+		URL: postmanURL{
+			Raw:      raw,
+			Host:     []string{"{{" + host + "}}"},
+			Path:     path,
+			Query:    query,
+			Variable: vars,
+		},
+	}
+	if body := doc.ReqBody.String(); len(body) > 0 {
+		request.Body = &postmanBody{
+			Mode: "raw",
+			Raw:  body,
+		}
+		if isJSON(contentTypeOrDefault(getContentType(doc.ReqHeaders))) {
+			request.Body.Options = map[string]interface{}{
+				"raw": map[string]string{"language": "json"},
+			}
+		}
+	}
+	return request
+}
+
+// postmanHeaders returns the common headers from the map
+func postmanHeaders(m *entry.Map) []postmanHeader {
+	res := make([]postmanHeader, 0)
+	m.Union().Values.Walk(func(k string, v interface{}) {
+		res = append(res, postmanHeader{Key: k, Value: entry.ToStrings(v).Join()})
+	})
+	return res
+}
+
+// postmanRequestHeaders returns the common request headers from the map,
+// without the ones Postman sets itself when the request is sent.
+func postmanRequestHeaders(m *entry.Map) []postmanHeader {
+	res := make([]postmanHeader, 0)
+	for _, v := range postmanHeaders(m) {
+		if !entry.IsClientHeader(v.Key) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
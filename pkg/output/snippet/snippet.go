@@ -0,0 +1,304 @@
+// Package snippet generates ready to run request snippets, such as cURL or Go
+// net/http, from a document, so outputs can show how to call each endpoint.
+package snippet
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// DefaultBaseURL is used for documents captured without a host, such as those
+// captured by the middleware.
+const DefaultBaseURL = "http://localhost"
+
+// Language is the language a snippet is written in
+type Language string
+
+const (
+	// Curl is a cURL command line
+	Curl Language = "curl"
+	// Go is a Go net/http program
+	Go Language = "go"
+	// JavaScript uses the fetch API
+	JavaScript Language = "javascript"
+	// Python uses the requests library
+	Python Language = "python"
+)
+
+// Languages is every language a snippet can be generated for
+var Languages = []Language{Curl, Go, JavaScript, Python}
+
+// Name returns a human readable name of the language
+func (l Language) Name() string {
+	switch l {
+	case Curl:
+		return "cURL"
+	case Go:
+		return "Go"
+	case JavaScript:
+		return "JavaScript"
+	case Python:
+		return "Python"
+	}
+	return string(l)
+}
+
+// Syntax returns the name used to highlight the language in a markdown code
+// block
+func (l Language) Syntax() string {
+	if l == Curl {
+		return "shell"
+	}
+	return string(l)
+}
+
+// Header is a single request header
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Request is the representative request of a document, that snippets are
+// generated from.
+type Request struct {
+	Method  string
+	URL     string
+	Headers []Header
+	Body    string
+}
+
+// NewRequest creates a Request from the common parts of a document. Path
+// variables and query parameters are filled in with their captured values.
+// If the document doesn't have a host, then the origin it was received on is
+// used, or the baseURL if there isn't one.
+func NewRequest(doc entry.Document, baseURL string) Request {
+	var (
+		hostPath = doc.URL.Union().HostPath
+		path     = hostPath.Path
+		query    = make(url.Values, 0)
+	)
+	doc.Params.Union().Values.Walk(func(k string, v interface{}) {
+		value := entry.ToStrings(v).Join()
+		if strings.Index(k, ":") == 0 {
+			path = replaceSegment(path, k, value)
+			return
+		}
+		query.Set(k, value)
+	})
+
+	base := baseURL
+	if len(hostPath.Host) > 0 {
+		base = scheme(doc) + "://" + hostPath.Host
+	} else if origin := union(doc.Origin); len(origin) > 0 {
+		base = origin
+	}
+	if len(base) < 1 {
+		base = DefaultBaseURL
+	}
+
+	u := strings.TrimSuffix(base, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var headers []Header
+	doc.ReqHeaders.Union().Values.Walk(func(k string, v interface{}) {
+		// These are set by the client when the request is sent, setting them
+		// by hand can change its behaviour, for example Accept-Encoding stops
+		// Go from decompressing the response.
+		if entry.IsClientHeader(k) {
+			return
+		}
+		headers = append(headers, Header{Name: k, Value: entry.ToStrings(v).Join()})
+	})
+
+	return Request{
+		Method:  doc.Method.Union().String,
+		URL:     u,
+		Headers: headers,
+		Body:    doc.ReqBody.String(),
+	}
+}
+
+// Generate returns a snippet of the request in the language
+func Generate(l Language, r Request) (string, error) {
+	switch l {
+	case Curl:
+		return curlSnippet(r), nil
+	case Go:
+		return goSnippet(r), nil
+	case JavaScript:
+		return javaScriptSnippet(r), nil
+	case Python:
+		return pythonSnippet(r), nil
+	}
+	return "", fmt.Errorf("unknown snippet language %q", l)
+}
+
+// ParseLanguages parses a list of languages separated by "|", where "all"
+// selects every language.
+func ParseLanguages(value string) ([]Language, error) {
+	var res []Language
+	for _, v := range strings.Split(value, "|") {
+		switch l := Language(strings.ToLower(strings.TrimSpace(v))); l {
+		case "":
+		case "all":
+			res = append(res, Languages...)
+		case Curl, Go, JavaScript, Python:
+			res = append(res, l)
+		default:
+			return nil, fmt.Errorf("unknown snippet language %q", v)
+		}
+	}
+	return res, nil
+}
+
+// curlSnippet returns the request as a cURL command line
+func curlSnippet(r Request) string {
+	lines := []string{fmt.Sprintf("curl -X %s %s", r.Method, shellQuote(r.URL))}
+	for _, v := range r.Headers {
+		lines = append(lines, fmt.Sprintf("  -H %s", shellQuote(v.Name+": "+v.Value)))
+	}
+	if len(r.Body) > 0 {
+		lines = append(lines, fmt.Sprintf("  --data-raw %s", shellQuote(r.Body)))
+	}
+	return strings.Join(lines, " \\\n") + "\n"
+}
+
+// goSnippet returns the request as a Go program using net/http
+func goSnippet(r Request) string {
+	var buf strings.Builder
+	buf.WriteString("package main\n\nimport (\n\t\"fmt\"\n\t\"io\"\n\t\"net/http\"\n")
+	if len(r.Body) > 0 {
+		buf.WriteString("\t\"strings\"\n")
+	}
+	buf.WriteString(")\n\nfunc main() {\n")
+
+	body := "nil"
+	if len(r.Body) > 0 {
+		body = fmt.Sprintf("strings.NewReader(%s)", goQuote(r.Body))
+	}
+	fmt.Fprintf(&buf, "\treq, err := http.NewRequest(%q, %q, %s)\n", r.Method, r.URL, body)
+	buf.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	for _, v := range r.Headers {
+		fmt.Fprintf(&buf, "\treq.Header.Set(%q, %q)\n", v.Name, v.Value)
+	}
+	buf.WriteString("\n\tresp, err := http.DefaultClient.Do(req)\n")
+	buf.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	buf.WriteString("\tdefer resp.Body.Close()\n\n")
+	buf.WriteString("\tbody, _ := io.ReadAll(resp.Body)\n")
+	buf.WriteString("\tfmt.Println(resp.Status, string(body))\n")
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// javaScriptSnippet returns the request using the fetch API
+func javaScriptSnippet(r Request) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "const response = await fetch(%s, {\n", quote(r.URL))
+	fmt.Fprintf(&buf, "  method: %s,\n", quote(r.Method))
+	if len(r.Headers) > 0 {
+		buf.WriteString("  headers: {\n")
+		for _, v := range r.Headers {
+			fmt.Fprintf(&buf, "    %s: %s,\n", quote(v.Name), quote(v.Value))
+		}
+		buf.WriteString("  },\n")
+	}
+	if len(r.Body) > 0 {
+		fmt.Fprintf(&buf, "  body: %s,\n", quote(r.Body))
+	}
+	buf.WriteString("});\n")
+	buf.WriteString("console.log(response.status, await response.text());\n")
+	return buf.String()
+}
+
+// pythonSnippet returns the request using the requests library
+func pythonSnippet(r Request) string {
+	var buf strings.Builder
+	buf.WriteString("import requests\n\n")
+	fmt.Fprintf(&buf, "response = requests.request(\n")
+	fmt.Fprintf(&buf, "    %s,\n", quote(r.Method))
+	fmt.Fprintf(&buf, "    %s,\n", quote(r.URL))
+	if len(r.Headers) > 0 {
+		buf.WriteString("    headers={\n")
+		for _, v := range r.Headers {
+			fmt.Fprintf(&buf, "        %s: %s,\n", quote(v.Name), quote(v.Value))
+		}
+		buf.WriteString("    },\n")
+	}
+	if len(r.Body) > 0 {
+		fmt.Fprintf(&buf, "    data=%s,\n", quote(r.Body))
+	}
+	buf.WriteString(")\n")
+	buf.WriteString("print(response.status_code, response.text)\n")
+	return buf.String()
+}
+
+// scheme returns the captured scheme of the document, which defaults to http.
+func scheme(doc entry.Document) string {
+	if s := union(doc.Scheme); len(s) > 0 {
+		return s
+	}
+	return "http"
+}
+
+// union returns the most common value of s, which may be nil.
+func union(s *entry.String) string {
+	if s == nil {
+		return ""
+	}
+	return s.Union().String
+}
+
+// replaceSegment replaces the path segment matching name with the value
+func replaceSegment(path, name, value string) string {
+	segments := strings.Split(path, "/")
+	for k, v := range segments {
+		if v == name {
+			segments[k] = url.PathEscape(value)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// goQuote uses a raw string literal where possible, as it's more readable for
+// JSON bodies.
+func goQuote(s string) string {
+	if !strings.Contains(s, "`") && !strings.Contains(s, "\r") {
+		return "`" + s + "`"
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// quote returns a double quoted string literal that's valid in both
+// JavaScript and Python.
+func quote(s string) string {
+	var buf strings.Builder
+	buf.WriteRune('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf.WriteRune('\\')
+			buf.WriteRune(r)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r < 0x20:
+			fmt.Fprintf(&buf, `\u%04x`, r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteRune('"')
+	return buf.String()
+}
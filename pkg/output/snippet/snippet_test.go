@@ -0,0 +1,106 @@
+package snippet
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	req := Request{
+		Method: "POST",
+		URL:    "http://localhost/users?expand=true",
+		Headers: []Header{
+			{Name: "Content-Type", Value: "application/json"},
+		},
+		Body: `{"name":"bob's"}`,
+	}
+
+	for l, expected := range map[Language][]string{
+		Curl: {
+			`curl -X POST 'http://localhost/users?expand=true' \`,
+			`  -H 'Content-Type: application/json' \`,
+			`  --data-raw '{"name":"bob'\''s"}'`,
+		},
+		Go: {
+			`req, err := http.NewRequest("POST", "http://localhost/users?expand=true", strings.NewReader(` + "`" + `{"name":"bob's"}` + "`" + `))`,
+			`req.Header.Set("Content-Type", "application/json")`,
+		},
+		JavaScript: {
+			`const response = await fetch("http://localhost/users?expand=true", {`,
+			`    "Content-Type": "application/json",`,
+			`  body: "{\"name\":\"bob's\"}",`,
+		},
+		Python: {
+			`    "POST",`,
+			`        "Content-Type": "application/json",`,
+			`    data="{\"name\":\"bob's\"}",`,
+		},
+	} {
+		code, err := Generate(l, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range expected {
+			if !strings.Contains(code, v) {
+				t.Errorf("%s: expected %q in:\n%s", l.Name(), v, code)
+			}
+		}
+	}
+}
+
+func TestParseLanguages(t *testing.T) {
+	t.Parallel()
+
+	languages, err := ParseLanguages("curl|Python")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := []Language{Curl, Python}, languages; len(expected) != len(actual) || expected[0] != actual[0] || expected[1] != actual[1] {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	if languages, err = ParseLanguages("all"); err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := len(Languages), len(languages); expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+
+	if _, err := ParseLanguages("cobol"); err == nil {
+		t.Error("expected error for an unknown language")
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	t.Parallel()
+
+	document := func(rawurl, origin string) entry.Document {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entry.Entries{
+			{URL: u, Origin: origin, Method: "GET", Status: 200, ReqBody: empty, RespBody: empty},
+		}.Document(entry.DefaultThresholds, false)
+	}
+
+	for _, v := range []struct {
+		url, origin, expected string
+	}{
+		{url: "https://example.com/users", expected: "https://example.com/users"},
+		{url: "http://example.com/users", expected: "http://example.com/users"},
+		{url: "/users", origin: "https://example.com", expected: "https://example.com/users"},
+		{url: "/users", expected: "http://localhost/users"},
+	} {
+		if actual := NewRequest(document(v.url, v.origin), DefaultBaseURL).URL; v.expected != actual {
+			t.Errorf("%q expected: %q, actual: %q", v.url, v.expected, actual)
+		}
+	}
+}
+
+func empty() []byte { return nil }
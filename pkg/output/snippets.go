@@ -0,0 +1,32 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+	"github.com/SimonRichardson/betwixt/pkg/output/snippet"
+)
+
+// writeSnippets writes a snippet of the document's request for each language,
+// with every line of the snippet indented.
+func writeSnippets(w io.Writer, format, indent string, doc entry.Document, languages []snippet.Language) error {
+	if len(languages) < 1 {
+		return nil
+	}
+
+	req := snippet.NewRequest(doc, snippet.DefaultBaseURL)
+	for _, l := range languages {
+		code, err := snippet.Generate(l, req)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, format, l.Name())
+		for _, v := range strings.Split(strings.TrimRight(code, "\n"), "\n") {
+			fmt.Fprintf(w, "%s%s\n", indent, v)
+		}
+		fmt.Fprintln(w, "")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// statsValue is a single named statistic of a document
+type statsValue struct {
+	Name  string
+	Value string
+}
+
+// statsValues returns the request count, latency and average sizes of the
+// document's entries.
+func statsValues(stats *entry.Stats) []statsValue {
+	return []statsValue{
+		{"Requests", fmt.Sprintf("%d", stats.Len())},
+		{"Latency", fmt.Sprintf("min %s, p50 %s, p95 %s, max %s",
+			formatDuration(stats.Min()),
+			formatDuration(stats.P50()),
+			formatDuration(stats.P95()),
+			formatDuration(stats.Max()),
+		)},
+		{"Request size", fmt.Sprintf("%s (average)", formatSize(stats.AvgReqSize()))},
+		{"Response size", fmt.Sprintf("%s (average)", formatSize(stats.AvgRespSize()))},
+	}
+}
+
+// writeStats writes the stats of the document, with every line indented.
+func writeStats(w io.Writer, header, indent string, stats *entry.Stats) {
+	fmt.Fprint(w, header)
+	for _, v := range statsValues(stats) {
+		fmt.Fprintf(w, "%s%s: %s\n", indent, v.Name, v.Value)
+	}
+	fmt.Fprintln(w, "")
+}
+
+// formatDuration rounds the duration, so it's readable
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
+
+func formatSize(size float64) string {
+	return fmt.Sprintf("%.0f B", size)
+}
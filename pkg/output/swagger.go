@@ -0,0 +1,291 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// SwaggerVersion is the version of the Swagger specification that is rendered
+const SwaggerVersion = "2.0"
+
+// Swagger2 renders a Swagger 2.0 document for tooling that doesn't support
+// OpenAPI 3.x. Schemas inferred from the bodies are written as definitions.
+type Swagger2 struct {
+	w       io.WriteCloser
+	options OpenAPIOptions
+}
+
+// NewSwagger2 creates a Swagger2 with the correct dependencies, only the first
+// server of the options is used as Swagger 2.0 only supports a single host.
+func NewSwagger2(w io.WriteCloser, options OpenAPIOptions) *Swagger2 {
+	return &Swagger2{w, options}
+}
+
+// Output takes a slice of documents and generates a Swagger 2.0 document from
+// them
+func (o Swagger2) Output(docs []entry.Document) error {
+	spec := swaggerSpec{
+		Swagger: SwaggerVersion,
+		Info: openAPIInfo{
+			Title:       o.options.Title,
+			Description: o.options.Description,
+			Version:     o.options.Version,
+		},
+		Paths:       make(map[string]map[string]*swaggerOperation, 0),
+		Definitions: make(map[string]*swaggerSchema, 0),
+	}
+	if len(o.options.Servers) > 0 {
+		server, err := url.Parse(o.options.Servers[0])
+		if err != nil {
+			return err
+		}
+		spec.Host = server.Host
+		spec.BasePath = server.Path
+		if len(server.Scheme) > 0 {
+			spec.Schemes = []string{server.Scheme}
+		}
+	}
+
+	ops := groupOperations(docs)
+	spec.Tags = openAPITags(ops)
+
+	operationIDs := make(map[string]int, 0)
+	for _, op := range ops {
+		path := templatePath(op.Path)
+		if _, ok := spec.Paths[path]; !ok {
+			spec.Paths[path] = make(map[string]*swaggerOperation, 0)
+		}
+
+		method := strings.ToLower(op.Method)
+		if !swaggerMethods[method] {
+			return fmt.Errorf("unsupported method %q for %s", op.Method, path)
+		}
+
+		id := uniqueOperationID(operationIDs, op.Method, path)
+		operation, err := newSwaggerOperation(op, id, spec.Definitions)
+		if err != nil {
+			return err
+		}
+		spec.Paths[path][method] = operation
+	}
+
+	bytes, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.w, "%s\n", bytes)
+
+	return o.w.Close()
+}
+
+// swaggerMethods are the methods a Swagger 2.0 path item can describe
+var swaggerMethods = map[string]bool{
+	"get":     true,
+	"put":     true,
+	"post":    true,
+	"delete":  true,
+	"options": true,
+	"head":    true,
+	"patch":   true,
+}
+
+type swaggerSpec struct {
+	Swagger     string                                  `json:"swagger"`
+	Info        openAPIInfo                             `json:"info"`
+	Host        string                                  `json:"host,omitempty"`
+	BasePath    string                                  `json:"basePath,omitempty"`
+	Schemes     []string                                `json:"schemes,omitempty"`
+	Tags        []openAPITag                            `json:"tags,omitempty"`
+	Paths       map[string]map[string]*swaggerOperation `json:"paths"`
+	Definitions map[string]*swaggerSchema               `json:"definitions,omitempty"`
+}
+
+type swaggerOperation struct {
+	Tags        []string                    `json:"tags,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	OperationID string                      `json:"operationId,omitempty"`
+	Consumes    []string                    `json:"consumes,omitempty"`
+	Produces    []string                    `json:"produces,omitempty"`
+	Parameters  []swaggerParameter          `json:"parameters,omitempty"`
+	Responses   map[string]*swaggerResponse `json:"responses"`
+}
+
+type swaggerParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Type     string         `json:"type,omitempty"`
+	Schema   *swaggerSchema `json:"schema,omitempty"`
+}
+
+type swaggerResponse struct {
+	Description string                   `json:"description"`
+	Headers     map[string]swaggerHeader `json:"headers,omitempty"`
+	Schema      *swaggerSchema           `json:"schema,omitempty"`
+	Examples    map[string]interface{}   `json:"examples,omitempty"`
+}
+
+type swaggerHeader struct {
+	Type string `json:"type"`
+}
+
+type swaggerSchema struct {
+	Ref        string                    `json:"$ref,omitempty"`
+	Type       string                    `json:"type,omitempty"`
+	Properties map[string]*swaggerSchema `json:"properties,omitempty"`
+	Required   []string                  `json:"required,omitempty"`
+	Items      *swaggerSchema            `json:"items,omitempty"`
+	Enum       []string                  `json:"enum,omitempty"`
+	Nullable   bool                      `json:"x-nullable,omitempty"`
+}
+
+func newSwaggerOperation(op operation, id string, definitions map[string]*swaggerSchema) (*swaggerOperation, error) {
+	res := &swaggerOperation{
+		OperationID: id,
+		Responses:   make(map[string]*swaggerResponse, 0),
+	}
+
+	// Parameters and annotations are shared with the OpenAPI output, as
+	// they're described in the same way.
+	openAPI, err := newOpenAPIOperation(op)
+	if err != nil {
+		return nil, err
+	}
+	res.Tags = openAPI.Tags
+	res.Summary = openAPI.Summary
+	res.Description = openAPI.Description
+	for _, v := range openAPI.Parameters {
+		res.Parameters = append(res.Parameters, swaggerParameter{
+			Name:     v.Name,
+			In:       v.In,
+			Required: v.Required,
+			Type:     "string",
+		})
+	}
+
+	var (
+		consumes = make(map[string]struct{}, 0)
+		produces = make(map[string]struct{}, 0)
+	)
+	for _, doc := range op.Docs {
+		if union := doc.ReqBody.String(); len(union) > 0 {
+			consumes[contentTypeOrDefault(getContentType(doc.ReqHeaders))] = struct{}{}
+
+			if !hasBodyParameter(res.Parameters) {
+				schema := &swaggerSchema{Type: "string"}
+				if inferred := doc.ReqSchema.JSONSchema(); inferred != nil {
+					name := definitionName(id, "Request")
+					definitions[name] = newSwaggerSchema(inferred)
+					schema = &swaggerSchema{Ref: "#/definitions/" + name}
+				}
+				res.Parameters = append(res.Parameters, swaggerParameter{
+					Name:     "body",
+					In:       "body",
+					Required: true,
+					Schema:   schema,
+				})
+			}
+		}
+
+		status := doc.Status.Union().Status
+		response := &swaggerResponse{
+			Description: statusDescription(status),
+		}
+		doc.RespHeaders.Union().Values.Walk(func(k string, v interface{}) {
+			if isReservedHeader(k) {
+				return
+			}
+			if response.Headers == nil {
+				response.Headers = make(map[string]swaggerHeader, 0)
+			}
+			response.Headers[k] = swaggerHeader{Type: "string"}
+		})
+		if union := doc.RespBody.String(); len(union) > 0 {
+			contentType := contentTypeOrDefault(getContentType(doc.RespHeaders))
+			produces[contentType] = struct{}{}
+
+			if inferred := doc.RespSchema.JSONSchema(); inferred != nil {
+				name := definitionName(id, "Response"+strconv.Itoa(status))
+				definitions[name] = newSwaggerSchema(inferred)
+				response.Schema = &swaggerSchema{Ref: "#/definitions/" + name}
+			}
+			response.Examples = map[string]interface{}{
+				contentType: exampleBody(contentType, union),
+			}
+		}
+		res.Responses[strconv.Itoa(status)] = response
+	}
+
+	res.Consumes = sortedKeys(consumes)
+	res.Produces = sortedKeys(produces)
+
+	return res, nil
+}
+
+// newSwaggerSchema converts an inferred JSON Schema into a Swagger 2.0 schema,
+// which only supports a single type and uses an extension for nullable values.
+func newSwaggerSchema(schema *entry.JSONSchema) *swaggerSchema {
+	res := &swaggerSchema{
+		Required: schema.Required,
+		Enum:     schema.Enum,
+		Nullable: schema.Nullable(),
+	}
+
+	var types []string
+	for _, v := range schema.Type {
+		if v != "null" {
+			types = append(types, v)
+		}
+	}
+	if len(types) == 1 {
+		res.Type = types[0]
+	}
+
+	if len(schema.Properties) > 0 {
+		res.Properties = make(map[string]*swaggerSchema, len(schema.Properties))
+		for k, v := range schema.Properties {
+			res.Properties[k] = newSwaggerSchema(v)
+		}
+	}
+	if schema.Items != nil {
+		res.Items = newSwaggerSchema(schema.Items)
+	} else if res.Type == "array" {
+		// Swagger 2.0 requires items for every array.
+		res.Items = &swaggerSchema{}
+	}
+
+	return res
+}
+
+func hasBodyParameter(params []swaggerParameter) bool {
+	for _, v := range params {
+		if v.In == "body" {
+			return true
+		}
+	}
+	return false
+}
+
+func definitionName(id, suffix string) string {
+	if len(id) < 1 {
+		return suffix
+	}
+	return strings.ToUpper(id[:1]) + id[1:] + suffix
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	var res []string
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,149 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// Executor is a parsed template, both text/template and html/template
+// templates are an Executor.
+type Executor interface {
+	Execute(io.Writer, interface{}) error
+}
+
+// TemplateData is the data a template is executed with
+type TemplateData struct {
+	Generated time.Time
+	Documents []entry.Document
+}
+
+// Template renders the documents through a user supplied template, so
+// different layouts don't need a new output.
+type Template struct {
+	w    io.WriteCloser
+	tmpl Executor
+}
+
+// NewTemplate creates a Template with the correct dependencies
+func NewTemplate(w io.WriteCloser, tmpl Executor) *Template {
+	return &Template{w, tmpl}
+}
+
+// ParseTemplateFile parses the template file at path, along with the
+// TemplateFuncs. Files ending in .html or .htm are parsed with html/template,
+// so the values are escaped, everything else with text/template.
+func ParseTemplateFile(path string) (Executor, error) {
+	name := filepath.Base(path)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return htmltemplate.New(name).Funcs(TemplateFuncs()).ParseFiles(path)
+	}
+	return template.New(name).Funcs(TemplateFuncs()).ParseFiles(path)
+}
+
+// TemplateFuncs returns the helper funcs that are available to templates
+//
+//	union        the Union of a Map, String, Status or URL
+//	difference   the Difference of a Map, String, Status or URL
+//	values       the sorted key and value pairs of Values
+//	json         pretty-prints a JSON string, or any other value as JSON
+//	score        formats a Score to two decimal places
+//	statusText   the text for a http status code
+//	path         a path with variables written as "{name}"
+func TemplateFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"union":      templateUnion,
+		"difference": templateDifference,
+		"values":     templateValues,
+		"json":       templateJSON,
+		"score":      templateScore,
+		"statusText": http.StatusText,
+		"path":       templatePath,
+	}
+}
+
+// Output takes a slice of documents and renders them through the template
+func (o Template) Output(docs []entry.Document) error {
+	data := TemplateData{
+		Generated: time.Now(),
+		Documents: docs,
+	}
+	if err := o.tmpl.Execute(o.w, data); err != nil {
+		o.w.Close()
+		return err
+	}
+	return o.w.Close()
+}
+
+// TemplateValue is a single key and value of Values, for ranging over in
+// templates.
+type TemplateValue struct {
+	Key   string
+	Value string
+}
+
+func templateUnion(x interface{}) (interface{}, error) {
+	switch t := x.(type) {
+	case *entry.Map:
+		return t.Union(), nil
+	case *entry.String:
+		return t.Union(), nil
+	case *entry.Status:
+		return t.Union(), nil
+	case *entry.URL:
+		return t.Union(), nil
+	}
+	return nil, fmt.Errorf("union: unexpected type %T", x)
+}
+
+func templateDifference(x interface{}) (interface{}, error) {
+	switch t := x.(type) {
+	case *entry.Map:
+		return t.Difference(), nil
+	case *entry.String:
+		return t.Difference(), nil
+	case *entry.Status:
+		return t.Difference(), nil
+	case *entry.URL:
+		return t.Difference(), nil
+	}
+	return nil, fmt.Errorf("difference: unexpected type %T", x)
+}
+
+func templateValues(values entry.Values) []TemplateValue {
+	var res []TemplateValue
+	values.Walk(func(k string, v interface{}) {
+		res = append(res, TemplateValue{Key: k, Value: entry.ToStrings(v).Join()})
+	})
+	return res
+}
+
+func templateJSON(x interface{}) (string, error) {
+	if s, ok := x.(string); ok {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(s), "", "  "); err != nil {
+			// Not everything is JSON, so leave it as it is.
+			return s, nil
+		}
+		return buf.String(), nil
+	}
+	bytes, err := json.MarshalIndent(x, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func templateScore(score entry.Score) string {
+	return fmt.Sprintf("%.2f", float64(score))
+}
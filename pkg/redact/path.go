@@ -0,0 +1,143 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	wildcard  = "*"
+	recursive = ""
+)
+
+// parsePath splits a path into its segments, where an empty segment matches
+// at any depth.
+func parsePath(path string) []string {
+	path = strings.TrimPrefix(path, "$")
+	path = strings.TrimPrefix(path, ".")
+	if strings.HasPrefix(path, ".") {
+		// Leading recursive descent, for example "$..token"
+		return append([]string{recursive}, strings.Split(path[1:], ".")...)
+	}
+	return strings.Split(path, ".")
+}
+
+// redactJSON redacts the values of the body that match the segments, returning
+// the original body when it isn't JSON or nothing matched. A body that looks
+// like JSON but can't be parsed, for example one cut off by the capture limit,
+// is redacted as a whole if it contains the keys of the path.
+func redactJSON(body []byte, segments []string, strategy Strategy) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) < 1 {
+		return body
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		if (trimmed[0] == '{' || trimmed[0] == '[') && containsKeys(body, segments) {
+			return []byte(strategy(""))
+		}
+		return body
+	}
+
+	doc, ok := redactValue(doc, segments, strategy)
+	if !ok {
+		return body
+	}
+
+	res, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return res
+}
+
+// containsKeys returns true if every key of the segments is found in the body,
+// wildcards match anything.
+func containsKeys(body []byte, segments []string) bool {
+	for _, v := range segments {
+		if v == wildcard || v == recursive {
+			continue
+		}
+		key, _ := json.Marshal(v)
+		if !bytes.Contains(body, key) {
+			return false
+		}
+	}
+	return true
+}
+
+func redactValue(value interface{}, segments []string, strategy Strategy) (interface{}, bool) {
+	if len(segments) < 1 {
+		if value == nil {
+			return nil, false
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return strategy(""), true
+		}
+		return strategy(fmt.Sprintf("%v", value)), true
+	}
+
+	var (
+		segment = segments[0]
+		matched bool
+	)
+
+	if segment == recursive {
+		// Either match the rest of the path here, or at any depth below.
+		value, matched = redactValue(value, segments[1:], strategy)
+		if next, ok := redactChildren(value, func(child interface{}) (interface{}, bool) {
+			return redactValue(child, segments, strategy)
+		}); ok {
+			value, matched = next, true
+		}
+		return value, matched
+	}
+
+	switch t := value.(type) {
+	case map[string]interface{}:
+		for k, v := range t {
+			if segment != wildcard && segment != k {
+				continue
+			}
+			if next, ok := redactValue(v, segments[1:], strategy); ok {
+				t[k], matched = next, true
+			}
+		}
+	case []interface{}:
+		for k, v := range t {
+			if segment != wildcard {
+				continue
+			}
+			if next, ok := redactValue(v, segments[1:], strategy); ok {
+				t[k], matched = next, true
+			}
+		}
+	}
+	return value, matched
+}
+
+func redactChildren(value interface{}, fn func(interface{}) (interface{}, bool)) (interface{}, bool) {
+	var matched bool
+	switch t := value.(type) {
+	case map[string]interface{}:
+		for k, v := range t {
+			if next, ok := fn(v); ok {
+				t[k], matched = next, true
+			}
+		}
+	case []interface{}:
+		for k, v := range t {
+			if next, ok := fn(v); ok {
+				t[k], matched = next, true
+			}
+		}
+	}
+	return value, matched
+}
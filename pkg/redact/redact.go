@@ -0,0 +1,230 @@
+// Package redact removes sensitive data from captured entries before they're
+// persisted or documented.
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// Rule redacts part of an entry, returning the redacted entry
+type Rule interface {
+	Redact(entry.Entry) entry.Entry
+}
+
+// Pipeline is a series of rules that are applied in order
+type Pipeline []Rule
+
+// New creates a Pipeline from a series of rules
+func New(rules ...Rule) Pipeline {
+	return Pipeline(rules)
+}
+
+// Redact applies every rule of the pipeline to the entry
+func (p Pipeline) Redact(e entry.Entry) entry.Entry {
+	for _, rule := range p {
+		e = rule.Redact(e)
+	}
+	return e
+}
+
+// Defaults returns a Pipeline with rules for common secrets; credentials in
+// headers, query parameters and JSON bodies, along with email addresses and
+// JSON Web Tokens anywhere in the entry. Credentials are replaced by a
+// placeholder, so nothing about them is published.
+func Defaults() Pipeline {
+	var (
+		rules    = make(Pipeline, 0)
+		redacted = Placeholder("REDACTED")
+	)
+	for _, v := range []string{
+		"Authorization",
+		"Proxy-Authorization",
+		"Cookie",
+		"Set-Cookie",
+		"X-Api-Key",
+		"X-Auth-Token",
+		"X-Csrf-Token",
+	} {
+		rules = append(rules, Header(v, redacted))
+	}
+	for _, v := range []string{
+		"access_token",
+		"api_key",
+		"apikey",
+		"password",
+		"secret",
+		"signature",
+		"token",
+	} {
+		rules = append(rules, Query(v, redacted))
+	}
+	for _, v := range []string{
+		"$..access_token",
+		"$..api_key",
+		"$..client_secret",
+		"$..password",
+		"$..refresh_token",
+		"$..secret",
+		"$..token",
+	} {
+		rules = append(rules, JSONPath(v, redacted))
+	}
+	return append(rules,
+		Regexp(emailPattern, Placeholder("user@example.com")),
+		Regexp(jwtPattern, redacted),
+	)
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
+	jwtPattern   = regexp.MustCompile(`eyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+`)
+)
+
+// RuleFunc is a type alias for using a func as a Rule
+type RuleFunc func(entry.Entry) entry.Entry
+
+// Redact calls the func
+func (f RuleFunc) Redact(e entry.Entry) entry.Entry {
+	return f(e)
+}
+
+// Header redacts the values of a request or response header
+func Header(name string, strategy Strategy) Rule {
+	name = http.CanonicalHeaderKey(name)
+	return RuleFunc(func(e entry.Entry) entry.Entry {
+		e.ReqHeaders = redactHeader(e.ReqHeaders, name, strategy)
+		e.RespHeaders = redactHeader(e.RespHeaders, name, strategy)
+		return e
+	})
+}
+
+// Query redacts the values of a query parameter
+func Query(key string, strategy Strategy) Rule {
+	return RuleFunc(func(e entry.Entry) entry.Entry {
+		if e.URL == nil {
+			return e
+		}
+		query := e.URL.Query()
+		values, ok := query[key]
+		if !ok {
+			return e
+		}
+		for k, v := range values {
+			values[k] = strategy(v)
+		}
+		e.URL = withQuery(e.URL, query)
+		return e
+	})
+}
+
+// JSONPath redacts the values of JSON request and response bodies that match
+// the path. Paths are dot separated keys, optionally starting with "$", where
+// "*" matches any key or array element and ".." matches at any depth, for
+// example "$.user.password" or "$..token".
+func JSONPath(path string, strategy Strategy) Rule {
+	segments := parsePath(path)
+	return RuleFunc(func(e entry.Entry) entry.Entry {
+		e.ReqBody = redactBody(e.ReqBody, func(body []byte) []byte {
+			return redactJSON(body, segments, strategy)
+		})
+		e.RespBody = redactBody(e.RespBody, func(body []byte) []byte {
+			return redactJSON(body, segments, strategy)
+		})
+		return e
+	})
+}
+
+// Regexp redacts every match of the expression in the path, header values,
+// query values and bodies of the entry
+func Regexp(expr *regexp.Regexp, strategy Strategy) Rule {
+	replace := func(value string) string {
+		return expr.ReplaceAllStringFunc(value, strategy)
+	}
+	return RuleFunc(func(e entry.Entry) entry.Entry {
+		e.ReqHeaders = redactHeaders(e.ReqHeaders, expr, replace)
+		e.RespHeaders = redactHeaders(e.RespHeaders, expr, replace)
+
+		if e.URL != nil && (expr.MatchString(e.URL.Path) || expr.MatchString(e.URL.RawPath)) {
+			u := *e.URL
+			u.Path = replace(u.Path)
+			// An escaped path that no longer matches the Path is ignored.
+			u.RawPath = replace(u.RawPath)
+			e.URL = &u
+		}
+		e.Template = replace(e.Template)
+
+		if e.URL != nil && expr.MatchString(e.URL.RawQuery) {
+			query := e.URL.Query()
+			for _, values := range query {
+				for k, v := range values {
+					values[k] = replace(v)
+				}
+			}
+			e.URL = withQuery(e.URL, query)
+		}
+
+		e.ReqBody = redactBody(e.ReqBody, func(body []byte) []byte {
+			return []byte(replace(string(body)))
+		})
+		e.RespBody = redactBody(e.RespBody, func(body []byte) []byte {
+			return []byte(replace(string(body)))
+		})
+		return e
+	})
+}
+
+// redactHeader returns a copy of the headers with the named header redacted,
+// the original headers belong to live requests and responses so are left
+// untouched.
+func redactHeader(h http.Header, name string, strategy Strategy) http.Header {
+	values, ok := h[name]
+	if !ok {
+		return h
+	}
+	res := h.Clone()
+	redacted := make([]string, len(values))
+	for k, v := range values {
+		redacted[k] = strategy(v)
+	}
+	res[name] = redacted
+	return res
+}
+
+func redactHeaders(h http.Header, expr *regexp.Regexp, replace func(string) string) http.Header {
+	var res http.Header
+	for name, values := range h {
+		for k, v := range values {
+			if !expr.MatchString(v) {
+				continue
+			}
+			if res == nil {
+				res = h.Clone()
+			}
+			res[name][k] = replace(v)
+		}
+	}
+	if res == nil {
+		return h
+	}
+	return res
+}
+
+func redactBody(body func() []byte, fn func([]byte) []byte) func() []byte {
+	if body == nil {
+		return nil
+	}
+	redacted := fn(body())
+	return func() []byte {
+		return redacted
+	}
+}
+
+func withQuery(u *url.URL, query url.Values) *url.URL {
+	res := *u
+	res.RawQuery = query.Encode()
+	return &res
+}
@@ -0,0 +1,146 @@
+package redact
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+func TestDefaults(t *testing.T) {
+	t.Parallel()
+
+	var (
+		u, _       = url.Parse("/login?token=abcdefghijklmnop&name=bob")
+		reqHeaders = http.Header{
+			"Authorization": []string{"Bearer abcdefghijklmnop"},
+			"Accept":        []string{"application/json"},
+		}
+		e = entry.Entry{
+			URL:        u,
+			Method:     "POST",
+			Status:     200,
+			ReqHeaders: reqHeaders,
+			ReqBody: func() []byte {
+				return []byte(`{"user":{"email":"bob@example.org","password":"hunter2"},"keep":1}`)
+			},
+			RespHeaders: http.Header{},
+			RespBody: func() []byte {
+				return []byte(`not json, bob@example.org`)
+			},
+		}
+	)
+
+	res := Defaults().Redact(e)
+
+	if expected, actual := "Bearer abcdefghijklmnop", reqHeaders.Get("Authorization"); expected != actual {
+		t.Errorf("expected original headers to be untouched: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := "REDACTED", res.ReqHeaders.Get("Authorization"); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := "REDACTED", res.URL.Query().Get("token"); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := "bob", res.URL.Query().Get("name"); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+
+	body := `{"keep":1,"user":{"email":"user@example.com","password":"REDACTED"}}`
+	if expected, actual := body, string(res.ReqBody()); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := "not json, user@example.com", string(res.RespBody()); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestMask(t *testing.T) {
+	t.Parallel()
+
+	if expected, actual := "*******************mnop", Mask("Bearer abcdefghijklmnop"); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := "*******", Mask("hunter2"); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	t.Parallel()
+
+	e := entry.Entry{
+		ReqBody: func() []byte {
+			return []byte(`{"items":[{"id":1,"card":"4111"},{"id":2,"card":"4242"}]}`)
+		},
+		RespBody: func() []byte {
+			return []byte(`{"items":[]}`)
+		},
+	}
+
+	res := JSONPath("$.items.*.card", Hash).Redact(e)
+
+	body := `{"items":[{"card":"` + Hash("4111") + `","id":1},{"card":"` + Hash("4242") + `","id":2}]}`
+	if expected, actual := body, string(res.ReqBody()); expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	if expected, actual := `{"items":[]}`, string(res.RespBody()); expected != actual {
+		t.Errorf("expected untouched body: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestJSONPathUnparsable(t *testing.T) {
+	t.Parallel()
+
+	for name, v := range map[string]struct {
+		body, expected string
+	}{
+		// A body cut off by the capture limit can't be parsed.
+		"truncated":   {`{"password":"hunter2","padding":"xxx...[truncated]`, "REDACTED"},
+		"without key": {`{"name":"bob","padding":"xxx...[truncated]`, `{"name":"bob","padding":"xxx...[truncated]`},
+		"not json":    {`password=hunter2`, `password=hunter2`},
+	} {
+		body := v.body
+		e := entry.Entry{
+			ReqBody: func() []byte {
+				return []byte(body)
+			},
+		}
+
+		res := JSONPath("$..password", Placeholder("REDACTED")).Redact(e)
+		if expected, actual := v.expected, string(res.ReqBody()); expected != actual {
+			t.Errorf("%s expected: %q, actual: %q", name, expected, actual)
+		}
+	}
+}
+
+func TestRegexpPath(t *testing.T) {
+	t.Parallel()
+
+	for _, rawurl := range []string{
+		"/users/alice@example.org/posts",
+		"/users/alice%40example.org/posts",
+		"/users/alice@example.org%2Fposts",
+	} {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := entry.Entry{
+			URL:      u,
+			Template: "/users/alice@example.org/posts",
+		}
+
+		res := Defaults().Redact(e)
+		for _, v := range []string{res.URL.Path, res.URL.EscapedPath(), res.URL.String(), res.Template} {
+			if strings.Contains(v, "alice") {
+				t.Errorf("%q expected redacted path, actual: %q", rawurl, v)
+			}
+		}
+		if expected, actual := rawurl, u.String(); expected != actual {
+			t.Errorf("expected original URL to be untouched: %q, actual: %q", expected, actual)
+		}
+	}
+}
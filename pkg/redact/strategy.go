@@ -0,0 +1,36 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Strategy replaces a sensitive value with something safe to publish
+type Strategy func(value string) string
+
+// Mask replaces the value with asterisks, keeping the last few characters of
+// long values so they can still be told apart. The length and the end of the
+// value are still published, so it's not suitable for credentials.
+func Mask(value string) string {
+	const visible = 4
+	if len(value) < visible*3 {
+		return strings.Repeat("*", len(value))
+	}
+	return strings.Repeat("*", len(value)-visible) + value[len(value)-visible:]
+}
+
+// Hash replaces the value with a truncated SHA-256 hash of it. The same value
+// always hashes the same, so the occurrence scores of the documents aren't
+// affected.
+func Hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// Placeholder replaces the value with the placeholder
+func Placeholder(placeholder string) Strategy {
+	return func(string) string {
+		return placeholder
+	}
+}
@@ -0,0 +1,47 @@
+// Package chiroute resolves the route patterns matched by a chi router, so
+// betwixt documents are grouped by the routes of the router.
+//
+//	router := chi.NewRouter()
+//	router.Get("/users/{id}", users)
+//
+//	capture := betwixt.New(router, outputs,
+//	    betwixt.WithRouteResolver(chiroute.NewResolver(router)),
+//	)
+package chiroute
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Resolver is a betwixt.RouteResolver for chi routers
+type Resolver struct {
+	routes chi.Routes
+}
+
+// NewResolver creates a Resolver for the router that betwixt wraps
+func NewResolver(routes chi.Routes) *Resolver {
+	return &Resolver{routes: routes}
+}
+
+// Prepare adds a route context to the request, which the router uses rather
+// than creating its own, so the matched pattern is still available once the
+// router has finished. The context refers to the router, as chi does, for the
+// middleware that match against it, such as middleware.GetHead.
+func (r *Resolver) Prepare(req *http.Request) *http.Request {
+	ctx := chi.NewRouteContext()
+	ctx.Routes = r.routes
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, ctx))
+}
+
+// Resolve returns the pattern of the route the router matched
+func (r *Resolver) Resolve(req *http.Request) (string, bool) {
+	ctx := chi.RouteContext(req.Context())
+	if ctx == nil {
+		return "", false
+	}
+	pattern := ctx.RoutePattern()
+	return pattern, len(pattern) > 0
+}
@@ -0,0 +1,45 @@
+package chiroute_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SimonRichardson/betwixt"
+	"github.com/SimonRichardson/betwixt/pkg/route/chiroute"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	router := chi.NewRouter()
+	// GetHead matches against the router of the route context.
+	router.Use(middleware.GetHead)
+	router.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	capture := betwixt.New(router, nil,
+		betwixt.WithRouteResolver(chiroute.NewResolver(router)),
+	)
+
+	for _, method := range []string{"GET", "HEAD"} {
+		w := httptest.NewRecorder()
+		capture.ServeHTTP(w, httptest.NewRequest(method, "/users/1", nil))
+		if expected, actual := http.StatusNoContent, w.Code; expected != actual {
+			t.Errorf("%s expected: %d, actual: %d", method, expected, actual)
+		}
+	}
+
+	entries := capture.Entries()
+	if expected, actual := 2, len(entries); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+	for _, v := range entries {
+		if expected, actual := "/users/:id", v.Template; expected != actual {
+			t.Errorf("%s expected: %q, actual: %q", v.Method, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// Package echoroute records the route paths matched by echo, so betwixt
+// documents are grouped by the routes of the router.
+//
+//	e := echo.New()
+//	e.Use(echoroute.Middleware)
+//	e.GET("/users/:id", users)
+//
+//	capture := betwixt.New(e, outputs,
+//	    betwixt.WithRouteResolver(betwixt.ContextResolver{}),
+//	)
+package echoroute
+
+import (
+	"github.com/SimonRichardson/betwixt"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware records the path of the matched route with betwixt.SetRoute. It
+// has to be added with Use, rather than Pre, as the route is only available
+// once echo has matched it.
+func Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		betwixt.SetRoute(c.Request(), c.Path())
+		return next(c)
+	}
+}
@@ -0,0 +1,39 @@
+package echoroute_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SimonRichardson/betwixt"
+	"github.com/SimonRichardson/betwixt/pkg/route/echoroute"
+	"github.com/labstack/echo/v4"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	e.Use(echoroute.Middleware)
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	capture := betwixt.New(e, nil,
+		betwixt.WithRouteResolver(betwixt.ContextResolver{}),
+	)
+
+	w := httptest.NewRecorder()
+	capture.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	if expected, actual := http.StatusNoContent, w.Code; expected != actual {
+		t.Errorf("expected: %d, actual: %d", expected, actual)
+	}
+
+	entries := capture.Entries()
+	if expected, actual := 1, len(entries); expected != actual {
+		t.Fatalf("expected: %d, actual: %d", expected, actual)
+	}
+	if expected, actual := "/users/:id", entries[0].Template; expected != actual {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
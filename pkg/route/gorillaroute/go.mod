@@ -0,0 +1,12 @@
+module github.com/SimonRichardson/betwixt/pkg/route/gorillaroute
+
+go 1.23.0
+
+require (
+	github.com/SimonRichardson/betwixt v0.0.0-00010101000000-000000000000
+	github.com/gorilla/mux v1.8.1
+)
+
+// The adapters are in their own modules, so betwixt itself doesn't depend on
+// any of the routers.
+replace github.com/SimonRichardson/betwixt => ../../..
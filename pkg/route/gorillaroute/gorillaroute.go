@@ -0,0 +1,32 @@
+// Package gorillaroute records the route templates matched by a gorilla/mux
+// router, so betwixt documents are grouped by the routes of the router.
+//
+//	router := mux.NewRouter()
+//	router.Use(gorillaroute.Middleware)
+//	router.HandleFunc("/users/{id}", users)
+//
+//	capture := betwixt.New(router, outputs,
+//	    betwixt.WithRouteResolver(betwixt.ContextResolver{}),
+//	)
+package gorillaroute
+
+import (
+	"net/http"
+
+	"github.com/SimonRichardson/betwixt"
+	"github.com/gorilla/mux"
+)
+
+// Middleware records the path template of the matched route with
+// betwixt.SetRoute. It has to be added to the router with Use, as the route is
+// only available once the router has matched it.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				betwixt.SetRoute(r, template)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
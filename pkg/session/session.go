@@ -0,0 +1,206 @@
+// Package session persists captured entries as JSON Lines, so documentation
+// can be rendered later by a separate process.
+package session
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// maxLineSize is the largest single encoded entry that can be read.
+const maxLineSize = 64 << 20
+
+// record defines the serialised form of an entry.Entry, bodies are encoded as
+// base64.
+type record struct {
+	URL         string      `json:"url"`
+	Origin      string      `json:"origin,omitempty"`
+	Template    string      `json:"template,omitempty"`
+	Started     time.Time   `json:"started"`
+	Duration    int64       `json:"duration_ns,omitempty"`
+	Method      string      `json:"method"`
+	Status      int         `json:"status"`
+	ReqHeaders  http.Header `json:"request_headers,omitempty"`
+	ReqBody     []byte      `json:"request_body,omitempty"`
+	RespHeaders http.Header `json:"response_headers,omitempty"`
+	RespBody    []byte      `json:"response_body,omitempty"`
+	ReqSize     int64       `json:"request_size,omitempty"`
+	RespSize    int64       `json:"response_size,omitempty"`
+	Summary     string      `json:"summary,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+}
+
+// Writer appends entries to a session, one entry per line.
+type Writer struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+// NewWriter creates a Writer that writes to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Create creates a Writer that appends to the file at path, creating it if it
+// doesn't exist.
+func Create(path string) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter(file), nil
+}
+
+// Write appends the entry to the session
+func (w *Writer) Write(e entry.Entry) error {
+	bytes, err := json.Marshal(record{
+		URL:         e.URL.String(),
+		Origin:      e.Origin,
+		Template:    e.Template,
+		Started:     e.Started,
+		Duration:    int64(e.Duration),
+		Method:      e.Method,
+		Status:      e.Status,
+		ReqHeaders:  e.ReqHeaders,
+		ReqBody:     e.ReqBody(),
+		RespHeaders: e.RespHeaders,
+		RespBody:    e.RespBody(),
+		ReqSize:     e.ReqSize,
+		RespSize:    e.RespSize,
+		Summary:     e.Annotations.Summary,
+		Description: e.Annotations.Description,
+		Tags:        e.Annotations.Tags,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	_, err = w.w.Write(append(bytes, '\n'))
+	return err
+}
+
+// Close closes the underlying writer, if it can be closed
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if closer, ok := w.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Read reads all the entries from a session
+func Read(r io.Reader) ([]entry.Entry, error) {
+	var (
+		res     []entry.Entry
+		scanner = bufio.NewScanner(r)
+		line    int
+	)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) < 1 {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		e, err := rec.entry()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		res = append(res, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ReadFile reads all the entries from a session file
+func ReadFile(path string) ([]entry.Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	res, err := Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return res, nil
+}
+
+// ReadFiles reads and merges all the entries from multiple session files, so
+// sessions from different processes can be documented together.
+func ReadFiles(paths ...string) ([]entry.Entry, error) {
+	var res []entry.Entry
+	for _, path := range paths {
+		entries, err := ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, entries...)
+	}
+	return res, nil
+}
+
+func (r record) entry() (entry.Entry, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return entry.Entry{}, err
+	}
+	var (
+		reqBody  = r.ReqBody
+		respBody = r.RespBody
+	)
+	return entry.Entry{
+		URL:        u,
+		Origin:     r.Origin,
+		Template:   r.Template,
+		Started:    r.Started,
+		Duration:   time.Duration(r.Duration),
+		Method:     r.Method,
+		Status:     r.Status,
+		ReqHeaders: headers(r.ReqHeaders),
+		ReqBody: func() []byte {
+			return reqBody
+		},
+		RespHeaders: headers(r.RespHeaders),
+		RespBody: func() []byte {
+			return respBody
+		},
+		ReqSize:  r.ReqSize,
+		RespSize: r.RespSize,
+		Annotations: entry.Annotations{
+			Summary:     r.Summary,
+			Description: r.Description,
+			Tags:        r.Tags,
+		},
+	}, nil
+}
+
+func headers(h http.Header) http.Header {
+	if h == nil {
+		return make(http.Header, 0)
+	}
+	return h
+}
@@ -0,0 +1,67 @@
+package betwixt
+
+import (
+	"context"
+	"net/http"
+)
+
+// RouteResolver asks the router which route pattern matched a request, so the
+// entries are grouped by the routes of the router, with their named path
+// parameters, rather than by the inferred templates.
+type RouteResolver interface {
+	// Prepare is called with the request before it's passed to the handler,
+	// so anything the router needs can be attached to it.
+	Prepare(*http.Request) *http.Request
+
+	// Resolve is called with the prepared request once the handler has
+	// finished, returning the pattern of the matched route.
+	Resolve(*http.Request) (string, bool)
+}
+
+// ServeMuxResolver resolves the patterns matched by a http.ServeMux, for
+// example "GET /users/{id}", from the Pattern of the request.
+type ServeMuxResolver struct{}
+
+// Prepare returns the request as it is
+func (ServeMuxResolver) Prepare(r *http.Request) *http.Request {
+	return r
+}
+
+// Resolve returns the pattern the http.ServeMux set on the request
+func (ServeMuxResolver) Resolve(r *http.Request) (string, bool) {
+	return r.Pattern, len(r.Pattern) > 0
+}
+
+// ContextResolver resolves the patterns recorded with SetRoute, for routers
+// that only expose the matched route to their own middleware.
+type ContextResolver struct{}
+
+type routeKey struct{}
+
+type routeSlot struct {
+	pattern string
+}
+
+// Prepare adds a slot to the request context for SetRoute to record the
+// pattern in.
+func (ContextResolver) Prepare(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, &routeSlot{}))
+}
+
+// Resolve returns the pattern recorded with SetRoute
+func (ContextResolver) Resolve(r *http.Request) (string, bool) {
+	slot, ok := r.Context().Value(routeKey{}).(*routeSlot)
+	if !ok {
+		return "", false
+	}
+	return slot.pattern, len(slot.pattern) > 0
+}
+
+// SetRoute records the pattern of the route that matched the request, for the
+// ContextResolver. Nothing is recorded if the request wasn't prepared by a
+// ContextResolver.
+func SetRoute(r *http.Request, pattern string) {
+	if slot, ok := r.Context().Value(routeKey{}).(*routeSlot); ok {
+		slot.pattern = pattern
+	}
+}
@@ -0,0 +1,144 @@
+package betwixt
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// store holds the captured entries, keeping at most a sample of the entries
+// for each endpoint and keeping their total size within a memory budget.
+type store struct {
+	sample int
+	budget int64
+	size   int64
+	seq    uint64
+	groups map[string]*reservoir
+	random *rand.Rand
+}
+
+// reservoir is a uniform random sample of the entries of a single endpoint,
+// so the scores of the documents are still representative of all the
+// entries, even though most of them have been discarded.
+type reservoir struct {
+	seen    int
+	limit   int
+	entries []storedEntry
+}
+
+type storedEntry struct {
+	seq   uint64
+	size  int64
+	entry entry.Entry
+}
+
+func newStore() *store {
+	return &store{
+		groups: make(map[string]*reservoir, 0),
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// add stores the entry, if it's chosen for the sample of its endpoint.
+func (s *store) add(e entry.Entry) {
+	key := e.SampleKey()
+	group, ok := s.groups[key]
+	if !ok {
+		group = &reservoir{limit: s.sample}
+		s.groups[key] = group
+	}
+	group.seen++
+
+	s.seq++
+	stored := storedEntry{
+		seq:   s.seq,
+		size:  entrySize(e),
+		entry: e,
+	}
+
+	switch {
+	case group.limit <= 0 || len(group.entries) < group.limit:
+		group.entries = append(group.entries, stored)
+	default:
+		// Algorithm R: the nth entry replaces a random entry of the sample
+		// with a probability of limit/n.
+		index := s.random.Intn(group.seen)
+		if index >= len(group.entries) {
+			return
+		}
+		s.size -= group.entries[index].size
+		group.entries[index] = stored
+	}
+	s.size += stored.size
+
+	s.evict()
+}
+
+// evict removes random entries from the largest samples, until the entries
+// fit within the budget. Those samples are then limited to their new size, so
+// they remain uniform.
+func (s *store) evict() {
+	if s.budget <= 0 {
+		return
+	}
+	for s.size > s.budget {
+		var group *reservoir
+		for _, v := range s.groups {
+			if group == nil || len(v.entries) > len(group.entries) {
+				group = v
+			}
+		}
+		if group == nil || len(group.entries) < 1 {
+			return
+		}
+
+		index := s.random.Intn(len(group.entries))
+		s.size -= group.entries[index].size
+
+		last := len(group.entries) - 1
+		group.entries[index] = group.entries[last]
+		group.entries = group.entries[:last]
+
+		group.limit = len(group.entries)
+		if group.limit < 1 {
+			group.limit = 1
+		}
+	}
+}
+
+// entries returns all the stored entries, in the order they were added.
+func (s *store) entries() []entry.Entry {
+	var stored []storedEntry
+	for _, v := range s.groups {
+		stored = append(stored, v.entries...)
+	}
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].seq < stored[j].seq
+	})
+
+	res := make([]entry.Entry, len(stored))
+	for k, v := range stored {
+		res[k] = v.entry
+	}
+	return res
+}
+
+// entrySize returns an estimate of the memory held by the entry.
+func entrySize(e entry.Entry) int64 {
+	size := len(e.URL.String()) + len(e.Method) + len(e.ReqBody()) + len(e.RespBody())
+	for k, v := range e.ReqHeaders {
+		size += len(k)
+		for _, value := range v {
+			size += len(value)
+		}
+	}
+	for k, v := range e.RespHeaders {
+		size += len(k)
+		for _, value := range v {
+			size += len(value)
+		}
+	}
+	return int64(size)
+}
@@ -0,0 +1,134 @@
+package betwixt
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SimonRichardson/betwixt/pkg/entry"
+)
+
+// Transport is a http.RoundTripper that captures all the requests sent by a
+// http.Client, so remote services can be documented in the same way as a
+// http.Handler.
+type Transport struct {
+	base    http.RoundTripper
+	capture *Betwixt
+}
+
+// NewTransport creates a Transport for possible outputs, if base is nil then
+// http.DefaultTransport is used.
+func NewTransport(base http.RoundTripper, outputs []Output, options ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		base:    base,
+		capture: New(nil, outputs, options...),
+	}
+}
+
+// RoundTrip sends the request using the base http.RoundTripper, the entry is
+// captured once the response body has been closed.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var (
+		reqBody     *requestBody
+		annotations entry.Annotations
+	)
+	// The original request must not be modified by a http.RoundTripper, so
+	// it's cloned before removing the annotations or capturing the body.
+	if hasAnnotations(req.Header) || req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+	}
+	if hasAnnotations(req.Header) {
+		annotations = takeAnnotations(req.Header)
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		// The body is copied as the base sends it, the base closes the body
+		// once it's been sent.
+		reqBody = newRequestBody(req.Body, t.capture.limit)
+		req.Body = reqBody
+	}
+
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &responseBody{
+		ReadCloser: resp.Body,
+		limit:      t.capture.limit,
+	}
+	body.done = func() {
+		var (
+			reqCapture []byte
+			reqSize    int64
+		)
+		if reqBody != nil {
+			reqCapture, reqSize = reqBody.Body(), reqBody.Size()
+		}
+		t.capture.record(entry.Entry{
+			URL:        req.URL,
+			Started:    started,
+			Duration:   time.Since(started),
+			Method:     req.Method,
+			Status:     resp.StatusCode,
+			ReqHeaders: req.Header,
+			ReqBody: func() []byte {
+				return reqCapture
+			},
+			RespHeaders: resp.Header,
+			RespBody: func() []byte {
+				return markTruncated(body.body.Bytes(), body.truncated)
+			},
+			ReqSize:     requestSize(req.ContentLength, reqSize),
+			RespSize:    body.size,
+			Annotations: annotations,
+		})
+	}
+	resp.Body = body
+
+	return resp, nil
+}
+
+// Output the results
+func (t *Transport) Output() error {
+	return t.capture.Output()
+}
+
+// responseBody copies the body (up to a limit) as it's read by the client.
+type responseBody struct {
+	io.ReadCloser
+	body      bytes.Buffer
+	limit     int64
+	truncated bool
+	size      int64
+	once      sync.Once
+	done      func()
+}
+
+func (r *responseBody) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.size += int64(n)
+	if remaining := r.limit - int64(r.body.Len()); int64(n) > remaining {
+		r.truncated = true
+		if remaining > 0 {
+			r.body.Write(p[:remaining])
+		}
+	} else {
+		r.body.Write(p[:n])
+	}
+	if err == io.EOF {
+		r.once.Do(r.done)
+	}
+	return n, err
+}
+
+func (r *responseBody) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.done)
+	return err
+}